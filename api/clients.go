@@ -0,0 +1,83 @@
+package api
+
+import (
+	"goauth/logics"
+	"net/http"
+)
+
+// Обработать HTTP запрос администратора для получения или регистрации клиентов OAuth 2.0.
+func HandleClients(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		handler := logics.ClientsQueryHandler{
+			Query: &logics.ClientsQuery{
+				AccessToken: bearerToken(r),
+			},
+		}
+
+		writeJson(w, handler.Handle())
+	case "POST":
+		var command logics.ClientCreationCommand
+		readJson(r, &command)
+		command.AccessToken = bearerToken(r)
+
+		handler := logics.ClientCreationCommandHandler{
+			Command: &command,
+		}
+
+		result := handler.Handle()
+
+		w.WriteHeader(201)
+		writeJson(w, result)
+	default:
+		w.WriteHeader(404)
+	}
+}
+
+// Обработать HTTP запрос администратора для изменения или удаления клиента OAuth 2.0.
+func HandleClient(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "PUT":
+		var command logics.ClientUpdateCommand
+		readJson(r, &command)
+		command.AccessToken = bearerToken(r)
+		command.Id = r.PathValue("id")
+
+		handler := logics.ClientUpdateCommandHandler{
+			Command: &command,
+		}
+
+		handler.Handle()
+	case "DELETE":
+		handler := logics.ClientDeletionCommandHandler{
+			Command: &logics.ClientDeletionCommand{
+				AccessToken: bearerToken(r),
+				Id:          r.PathValue("id"),
+			},
+		}
+
+		handler.Handle()
+	default:
+		w.WriteHeader(404)
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+// Обработать HTTP запрос администратора для замены секрета клиента OAuth 2.0.
+func HandleClientSecretRotation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(404)
+		return
+	}
+
+	handler := logics.ClientSecretRotationCommandHandler{
+		Command: &logics.ClientSecretRotationCommand{
+			AccessToken: bearerToken(r),
+			Id:          r.PathValue("id"),
+		},
+	}
+
+	writeJson(w, handler.Handle())
+}
@@ -1,7 +1,10 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"goauth/logics"
 	"net/http"
 )
 
@@ -13,8 +16,7 @@ func ErrorsHandler(next http.Handler) http.Handler {
 			if recovered != nil {
 				err, ok := recovered.(error)
 				if ok {
-					w.WriteHeader(500)
-					fmt.Fprint(w, err.Error())
+					writeError(w, err)
 				}
 			}
 		}()
@@ -22,3 +24,41 @@ func ErrorsHandler(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// Записать ошибку в ответ. Ошибки протокола OAuth 2.0 записываются в формате JSON (RFC 6749, раздел 5.2).
+func writeError(w http.ResponseWriter, err error) {
+	var oauthErr *logics.OAuthError
+	if !errors.As(err, &oauthErr) {
+		w.WriteHeader(statusCode(err))
+		fmt.Fprint(w, err.Error())
+		return
+	}
+
+	json, marshalErr := json.Marshal(map[string]string{
+		"error":             oauthErr.Code,
+		"error_description": oauthErr.Description,
+	})
+	if marshalErr != nil {
+		panic(marshalErr)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode(err))
+	fmt.Fprint(w, string(json))
+}
+
+func statusCode(err error) int {
+	switch {
+	case errors.Is(err, logics.ErrUnauthorized):
+		return 401
+	case errors.Is(err, logics.ErrInvalidRequest):
+		return 400
+	case errors.Is(err, logics.ErrForbidden):
+		return 403
+	case errors.Is(err, logics.ErrNotFound):
+		return 404
+	}
+
+	return 500
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"goauth/logics"
+	"net/http"
+	"net/url"
+)
+
+// Обработать HTTP запрос для получения сведений о токене (RFC 7662).
+func HandleIntrospection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(404)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		panic(fmt.Errorf("%w: %w", logics.ErrInvalidRequest, err))
+	}
+
+	clientId, clientSecret := clientCredentials(r)
+
+	handler := logics.IntrospectionQueryHandler{
+		Query: &logics.IntrospectionQuery{
+			Token:         r.PostForm.Get("token"),
+			TokenTypeHint: r.PostForm.Get("token_type_hint"),
+			ClientId:      clientId,
+			ClientSecret:  clientSecret,
+		},
+	}
+
+	result := handler.Handle()
+
+	json, err := json.Marshal(result)
+	if err != nil {
+		panic(err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprint(w, string(json))
+}
+
+// Получить учетные данные клиента из заголовка Authorization или из параметров формы запроса.
+func clientCredentials(r *http.Request) (string, string) {
+	clientId, clientSecret, ok := r.BasicAuth()
+	if ok {
+		return unescape(clientId), unescape(clientSecret)
+	}
+
+	return r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+}
+
+// Раскодировать значение учетных данных клиента, закодированное по RFC 6749 (раздел 2.3.1).
+func unescape(value string) string {
+	result, err := url.QueryUnescape(value)
+	if err != nil {
+		return value
+	}
+
+	return result
+}
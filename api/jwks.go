@@ -0,0 +1,33 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"goauth/logics"
+	"net/http"
+)
+
+// Время в секундах, в течение которого клиенты могут кэшировать набор ключей.
+const JWKS_MAX_AGE_IN_SECONDS = 300
+
+// Обработать HTTP запрос для получения ключей проверки подписи ACCESS токенов.
+func HandleJwks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.WriteHeader(404)
+		return
+	}
+
+	handler := logics.JwksQueryHandler{}
+
+	result := handler.Handle()
+
+	json, err := json.Marshal(result)
+	if err != nil {
+		panic(err)
+	}
+
+	w.Header().Set("Content-Type", "application/jwk-set+json")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", JWKS_MAX_AGE_IN_SECONDS))
+
+	fmt.Fprint(w, string(json))
+}
@@ -4,8 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"goauth/logics"
+	"io"
 	"net/http"
-	"strconv"
 	"strings"
 )
 
@@ -16,20 +16,12 @@ func HandleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userId, err := strconv.Atoi(r.URL.Query().Get("userId"))
-	if err != nil {
-		panic(err)
-	}
-
-	userIp := strings.Split(r.RemoteAddr, ":")[0]
-
-	command := logics.LoginCommand{
-		UserId: int32(userId),
-		UserIp: userIp,
-	}
+	command := readLoginBody(r)
+	command.UserIp = strings.Split(r.RemoteAddr, ":")[0]
+	command.UserAgent = r.UserAgent()
 
 	handler := logics.LoginCommandHandler{
-		Command: &command,
+		Command: command,
 	}
 
 	result := handler.Handle()
@@ -41,3 +33,20 @@ func HandleLogin(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Fprint(w, string(json))
 }
+
+func readLoginBody(r *http.Request) *logics.LoginCommand {
+	defer r.Body.Close()
+
+	bytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		panic(err)
+	}
+
+	var command logics.LoginCommand
+	err = json.Unmarshal(bytes, &command)
+	if err != nil {
+		panic(err)
+	}
+
+	return &command
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"encoding/json"
+	"goauth/logics"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Обработать HTTP запрос для завершения сеанса пользователя.
+func HandleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(404)
+		return
+	}
+
+	command := readLogoutBody(r)
+	command.UserIp = strings.Split(r.RemoteAddr, ":")[0]
+
+	handler := logics.LogoutCommandHandler{
+		Command: command,
+	}
+
+	handler.Handle()
+
+	w.WriteHeader(204)
+}
+
+func readLogoutBody(r *http.Request) *logics.LogoutCommand {
+	defer r.Body.Close()
+
+	bytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		panic(err)
+	}
+
+	var command logics.LogoutCommand
+	err = json.Unmarshal(bytes, &command)
+	if err != nil {
+		panic(err)
+	}
+
+	return &command
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"goauth/logics"
+	"goauth/logics/services"
+	"net/http"
+	"strings"
+)
+
+// Имя cookie, в котором браузер, запросивший ссылку для входа, хранит одноразовое значение.
+const MAGIC_LINK_NONCE_COOKIE_NAME = "goauth_magic_link_nonce"
+
+// Путь cookie с одноразовым значением: оно передается только в запросах к ссылкам для входа.
+const MAGIC_LINK_NONCE_COOKIE_PATH = "/auth/magic-link"
+
+// Обработать HTTP запрос для отправки пользователю ссылки для входа без пароля.
+func HandleMagicLink(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(404)
+		return
+	}
+
+	var command logics.MagicLinkCommand
+	readJson(r, &command)
+
+	handler := logics.MagicLinkCommandHandler{
+		Command: &command,
+	}
+
+	result := handler.Handle()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     MAGIC_LINK_NONCE_COOKIE_NAME,
+		Value:    result.Nonce,
+		Path:     MAGIC_LINK_NONCE_COOKIE_PATH,
+		MaxAge:   int(services.MAGIC_LINK_TOKEN_LIFETIME.Seconds()),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	w.WriteHeader(202)
+}
+
+// Обработать HTTP запрос для входа по ссылке из письма.
+func HandleMagicLinkConsumption(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(404)
+		return
+	}
+
+	var command logics.MagicLinkConsumptionCommand
+	readJson(r, &command)
+	command.Nonce = magicLinkNonce(r)
+	command.UserIp = strings.Split(r.RemoteAddr, ":")[0]
+	command.UserAgent = r.UserAgent()
+
+	handler := logics.MagicLinkConsumptionCommandHandler{
+		Command: &command,
+	}
+
+	result := handler.Handle()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     MAGIC_LINK_NONCE_COOKIE_NAME,
+		Path:     MAGIC_LINK_NONCE_COOKIE_PATH,
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	writeJson(w, result)
+}
+
+// Получить одноразовое значение из cookie. Пустая строка, если cookie не передан.
+func magicLinkNonce(r *http.Request) string {
+	cookie, err := r.Cookie(MAGIC_LINK_NONCE_COOKIE_NAME)
+	if err != nil {
+		return ""
+	}
+
+	return cookie.Value
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"goauth/logics"
+	"net/http"
+	"strings"
+)
+
+// Обработать HTTP запрос для начала подключения второго фактора TOTP.
+func HandleTotpEnrollment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(404)
+		return
+	}
+
+	handler := logics.TotpEnrollmentCommandHandler{
+		Command: &logics.TotpEnrollmentCommand{
+			AccessToken: bearerToken(r),
+		},
+	}
+
+	writeJson(w, handler.Handle())
+}
+
+// Обработать HTTP запрос для подтверждения подключения второго фактора TOTP.
+func HandleTotpConfirmation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(404)
+		return
+	}
+
+	var command logics.TotpConfirmationCommand
+	readJson(r, &command)
+	command.AccessToken = bearerToken(r)
+
+	handler := logics.TotpConfirmationCommandHandler{
+		Command: &command,
+	}
+
+	writeJson(w, handler.Handle())
+}
+
+// Обработать HTTP запрос для завершения аутентификации проверкой второго фактора.
+func HandleMfaVerification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(404)
+		return
+	}
+
+	var command logics.MfaVerificationCommand
+	readJson(r, &command)
+	command.UserIp = strings.Split(r.RemoteAddr, ":")[0]
+	command.UserAgent = r.UserAgent()
+
+	handler := logics.MfaVerificationCommandHandler{
+		Command: &command,
+	}
+
+	writeJson(w, handler.Handle())
+}
+
+// Записать указанное значение в ответ в формате JSON.
+func writeJson(w http.ResponseWriter, value any) {
+	json, err := json.Marshal(value)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Fprint(w, string(json))
+}
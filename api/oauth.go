@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"goauth/logics"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Обработать HTTP запрос на авторизацию OAuth 2.0.
+//
+// Запрос GET поступает из браузера пользователя и перенаправляется на страницу авторизации клиентского приложения.
+// Запрос POST поступает со страницы авторизации с ACCESS токеном пользователя и возвращает адрес перенаправления клиента с кодом авторизации.
+func HandleAuthorization(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		handler := logics.AuthorizationQueryHandler{
+			Query: &logics.AuthorizationQuery{
+				Request: authorizationRequest(r.URL.Query()),
+			},
+		}
+
+		http.Redirect(w, r, handler.Handle(), http.StatusFound)
+	case "POST":
+		err := r.ParseForm()
+		if err != nil {
+			panic(err)
+		}
+
+		handler := logics.AuthorizationCommandHandler{
+			Command: &logics.AuthorizationCommand{
+				AccessToken: bearerToken(r),
+				Request:     authorizationRequest(r.Form),
+			},
+		}
+
+		writeJson(w, handler.Handle())
+	default:
+		w.WriteHeader(404)
+	}
+}
+
+// Обработать HTTP запрос к конечной точке токенов OAuth 2.0.
+func HandleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(404)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		panic(err)
+	}
+
+	clientId, clientSecret := clientCredentials(r)
+
+	handler := logics.TokenCommandHandler{
+		Command: &logics.TokenCommand{
+			GrantType:    r.PostForm.Get("grant_type"),
+			Code:         r.PostForm.Get("code"),
+			RedirectUri:  r.PostForm.Get("redirect_uri"),
+			ClientId:     clientId,
+			ClientSecret: clientSecret,
+			CodeVerifier: r.PostForm.Get("code_verifier"),
+			RefreshToken: r.PostForm.Get("refresh_token"),
+			Scope:        r.PostForm.Get("scope"),
+			UserIp:       strings.Split(r.RemoteAddr, ":")[0],
+			UserAgent:    r.UserAgent(),
+		},
+	}
+
+	result := handler.Handle()
+
+	json, err := json.Marshal(result)
+	if err != nil {
+		panic(err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprint(w, string(json))
+}
+
+// Получить параметры запроса на авторизацию из строки запроса или формы.
+func authorizationRequest(values url.Values) logics.AuthorizationRequest {
+	return logics.AuthorizationRequest{
+		ResponseType:        values.Get("response_type"),
+		ClientId:            values.Get("client_id"),
+		RedirectUri:         values.Get("redirect_uri"),
+		Scope:               values.Get("scope"),
+		State:               values.Get("state"),
+		CodeChallenge:       values.Get("code_challenge"),
+		CodeChallengeMethod: values.Get("code_challenge_method"),
+		Nonce:               values.Get("nonce"),
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"goauth/logics"
+	"net/http"
+)
+
+// Время в секундах, в течение которого клиенты могут кэшировать метаданные поставщика OpenID Connect.
+const OPENID_CONFIGURATION_MAX_AGE_IN_SECONDS = 3600
+
+// Обработать HTTP запрос для получения метаданных поставщика OpenID Connect.
+func HandleOpenIdConfiguration(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.WriteHeader(404)
+		return
+	}
+
+	handler := logics.OpenIdConfigurationQueryHandler{}
+
+	result := handler.Handle()
+
+	json, err := json.Marshal(result)
+	if err != nil {
+		panic(err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", OPENID_CONFIGURATION_MAX_AGE_IN_SECONDS))
+
+	fmt.Fprint(w, string(json))
+}
+
+// Обработать HTTP запрос для получения утверждений о пользователе по ACCESS токену.
+//
+// По OpenID Connect Core 1.0 (раздел 5.3.1) принимаются запросы GET и POST.
+func HandleUserInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "POST" {
+		w.WriteHeader(404)
+		return
+	}
+
+	handler := logics.UserInfoQueryHandler{
+		Query: &logics.UserInfoQuery{
+			AccessToken: bearerToken(r),
+		},
+	}
+
+	result := handler.Handle()
+
+	json, err := json.Marshal(result)
+	if err != nil {
+		panic(err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprint(w, string(json))
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"goauth/logics"
+	"net/http"
+)
+
+// Обработать HTTP запрос для отправки пользователю ссылки для сброса пароля.
+//
+// Ответ 202 возвращается независимо от того, зарегистрирован ли адрес.
+func HandleForgotPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(404)
+		return
+	}
+
+	var command logics.ForgotPasswordCommand
+	readJson(r, &command)
+
+	handler := logics.ForgotPasswordCommandHandler{
+		Command: &command,
+	}
+
+	handler.Handle()
+
+	w.WriteHeader(202)
+}
+
+// Обработать HTTP запрос для задания нового пароля по токену сброса пароля.
+func HandlePasswordReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(404)
+		return
+	}
+
+	var command logics.PasswordResetCommand
+	readJson(r, &command)
+
+	handler := logics.PasswordResetCommandHandler{
+		Command: &command,
+	}
+
+	handler.Handle()
+
+	w.WriteHeader(204)
+}
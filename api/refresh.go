@@ -18,6 +18,7 @@ func HandleRefresh(w http.ResponseWriter, r *http.Request) {
 
 	command := readBody(r)
 	command.UserIp = strings.Split(r.RemoteAddr, ":")[0]
+	command.UserAgent = r.UserAgent()
 
 	handler := logics.RefreshCommandHandler{
 		Command: command,
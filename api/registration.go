@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"goauth/logics"
+	"io"
+	"net/http"
+)
+
+// Обработать HTTP запрос для регистрации пользователя.
+//
+// Ответ 202 возвращается в том числе для уже зарегистрированного адреса.
+func HandleRegistration(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(404)
+		return
+	}
+
+	var command logics.RegistrationCommand
+	readJson(r, &command)
+
+	handler := logics.RegistrationCommandHandler{
+		Command: &command,
+	}
+
+	handler.Handle()
+
+	w.WriteHeader(202)
+}
+
+// Обработать HTTP запрос для подтверждения адреса электронной почты пользователя.
+func HandleEmailVerification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(404)
+		return
+	}
+
+	var command logics.EmailVerificationCommand
+	readJson(r, &command)
+
+	handler := logics.EmailVerificationCommandHandler{
+		Command: &command,
+	}
+
+	handler.Handle()
+
+	w.WriteHeader(204)
+}
+
+// Прочитать тело запроса в формате JSON в указанное значение.
+func readJson(r *http.Request, value any) {
+	defer r.Body.Close()
+
+	bytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		panic(err)
+	}
+
+	err = json.Unmarshal(bytes, value)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"fmt"
+	"goauth/logics"
+	"net/http"
+)
+
+// Обработать HTTP запрос для отзыва токена (RFC 7009).
+//
+// Ответ 200 возвращается независимо от того, был ли токен известен сервису. Если клиент не аутентифицирован, возвращается 401.
+func HandleRevocation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(404)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		panic(fmt.Errorf("%w: %w", logics.ErrInvalidRequest, err))
+	}
+
+	clientId, clientSecret := clientCredentials(r)
+
+	handler := logics.TokenRevocationCommandHandler{
+		Command: &logics.TokenRevocationCommand{
+			Token:         r.PostForm.Get("token"),
+			TokenTypeHint: r.PostForm.Get("token_type_hint"),
+			ClientId:      clientId,
+			ClientSecret:  clientSecret,
+		},
+	}
+
+	handler.Handle()
+
+	w.WriteHeader(200)
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"goauth/logics"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Обработать HTTP запрос для получения активных сеансов пользователя.
+func HandleSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.WriteHeader(404)
+		return
+	}
+
+	handler := logics.SessionsQueryHandler{
+		Query: &logics.SessionsQuery{
+			AccessToken: bearerToken(r),
+		},
+	}
+
+	result := handler.Handle()
+
+	json, err := json.Marshal(result)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Fprint(w, string(json))
+}
+
+// Обработать HTTP запрос для завершения сеанса пользователя.
+func HandleSessionRevocation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "DELETE" {
+		w.WriteHeader(404)
+		return
+	}
+
+	sessionId, err := strconv.ParseInt(r.PathValue("id"), 10, 32)
+	if err != nil {
+		panic(fmt.Errorf("%w: invalid session id: %w", logics.ErrInvalidRequest, err))
+	}
+
+	handler := logics.SessionRevocationCommandHandler{
+		Command: &logics.SessionRevocationCommand{
+			AccessToken: bearerToken(r),
+			SessionId:   int32(sessionId),
+		},
+	}
+
+	handler.Handle()
+
+	w.WriteHeader(204)
+}
+
+// Обработать HTTP запрос для завершения всех сеансов пользователя, кроме текущего.
+func HandleOtherSessionsRevocation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(404)
+		return
+	}
+
+	handler := logics.OtherSessionsRevocationCommandHandler{
+		Command: &logics.OtherSessionsRevocationCommand{
+			AccessToken: bearerToken(r),
+		},
+	}
+
+	handler.Handle()
+
+	w.WriteHeader(204)
+}
+
+// Получить ACCESS токен из заголовка Authorization запроса.
+func bearerToken(r *http.Request) string {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
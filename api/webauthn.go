@@ -0,0 +1,76 @@
+package api
+
+import (
+	"goauth/logics"
+	"net/http"
+	"strings"
+)
+
+// Обработать HTTP запрос для начала регистрации учетных данных WebAuthn.
+func HandleWebAuthnRegistrationOptions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(404)
+		return
+	}
+
+	handler := logics.WebAuthnRegistrationOptionsCommandHandler{
+		Command: &logics.WebAuthnRegistrationOptionsCommand{
+			AccessToken: bearerToken(r),
+		},
+	}
+
+	writeJson(w, handler.Handle())
+}
+
+// Обработать HTTP запрос для завершения регистрации учетных данных WebAuthn.
+func HandleWebAuthnRegistration(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(404)
+		return
+	}
+
+	var command logics.WebAuthnRegistrationCommand
+	readJson(r, &command)
+	command.AccessToken = bearerToken(r)
+
+	handler := logics.WebAuthnRegistrationCommandHandler{
+		Command: &command,
+	}
+
+	handler.Handle()
+
+	w.WriteHeader(204)
+}
+
+// Обработать HTTP запрос для начала аутентификации учетными данными WebAuthn.
+func HandleWebAuthnLoginOptions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(404)
+		return
+	}
+
+	handler := logics.WebAuthnLoginOptionsCommandHandler{
+		Command: &logics.WebAuthnLoginOptionsCommand{},
+	}
+
+	writeJson(w, handler.Handle())
+}
+
+// Обработать HTTP запрос для завершения аутентификации учетными данными WebAuthn.
+func HandleWebAuthnLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(404)
+		return
+	}
+
+	var command logics.WebAuthnLoginCommand
+	readJson(r, &command)
+	command.UserIp = strings.Split(r.RemoteAddr, ":")[0]
+	command.UserAgent = r.UserAgent()
+
+	handler := logics.WebAuthnLoginCommandHandler{
+		Command: &command,
+	}
+
+	writeJson(w, handler.Handle())
+}
@@ -0,0 +1,149 @@
+package authorizationcodes
+
+import (
+	"fmt"
+	"goauth/data"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Проекция таблицы AUTHORIZATION_CODES.
+type AuthorizationCode struct {
+	// Идентификатор кода авторизации.
+	Id int32
+
+	// Идентификатор клиента OAuth 2.0, которому выдан код.
+	ClientId string
+
+	// Идентификатор пользователя, разрешившего доступ.
+	UserId int32
+
+	// Дайджест HMAC-SHA256 от кода авторизации.
+	CodeHash string
+
+	// Адрес перенаправления, указанный в запросе на авторизацию.
+	RedirectUri string
+
+	// Вызов PKCE (RFC 7636): SHA-256 от секрета клиента в кодировке Base64URL.
+	CodeChallenge string
+
+	// Запрошенные области доступа, разделенные пробелами.
+	Scope string
+
+	// Способы аутентификации, использованные пользователем при начале сеанса (RFC 8176).
+	Amr []string
+
+	// Значение nonce из запроса на авторизацию OpenID Connect. Пустая строка, если не передано.
+	Nonce string
+
+	// Момент времени начала сеанса пользователя, в рамках которого выдан код.
+	AuthTime time.Time
+
+	// Момент времени, до которого код считается действительным.
+	ExpiresAt time.Time
+
+	// Момент времени, когда код был обменян на токены. nil, если код еще не использован.
+	UsedAt *time.Time
+}
+
+// Репозиторий таблицы AUTHORIZATION_CODES.
+type Repository struct {
+	// Контекст подключения к БД.
+	Context data.Context
+}
+
+// Столбцы таблицы AUTHORIZATION_CODES в порядке их чтения.
+const columns = "ID, CLIENT_ID, USER_ID, CODE_HASH, REDIRECT_URI, CODE_CHALLENGE, SCOPE, AMR, NONCE, AUTH_TIME, EXPIRES_AT, USED_AT"
+
+// Получить код авторизации по идентификатору.
+func (s Repository) Get(id int32) (*AuthorizationCode, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("SELECT %s FROM AUTHORIZATION_CODES WHERE ID = %d", columns, id)
+
+	row := db.QueryRow(sql)
+
+	return scan(row)
+}
+
+// Создать код авторизации. Удаляет коды с истекшим сроком действия.
+func (s Repository) Create(t AuthorizationCode) (*AuthorizationCode, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	_, err = db.Exec("DELETE FROM AUTHORIZATION_CODES WHERE EXPIRES_AT <= NOW()")
+	if err != nil {
+		return nil, err
+	}
+
+	sql := fmt.Sprintf("INSERT INTO AUTHORIZATION_CODES (CLIENT_ID, USER_ID, CODE_HASH, REDIRECT_URI, CODE_CHALLENGE, SCOPE, AMR, NONCE, AUTH_TIME, EXPIRES_AT) "+
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING %s", columns)
+
+	row := db.QueryRow(sql, t.ClientId, t.UserId, t.CodeHash, t.RedirectUri, t.CodeChallenge, t.Scope, pq.Array(t.Amr), t.Nonce, t.AuthTime, t.ExpiresAt)
+
+	return scan(row)
+}
+
+// Обновить дайджест кода авторизации.
+func (s Repository) UpdateCodeHash(id int32, codeHash string) error {
+	db, err := s.Context.Open()
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	_, err = db.Exec("UPDATE AUTHORIZATION_CODES SET CODE_HASH = $1 WHERE ID = $2", codeHash, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Отметить код авторизации как использованный.
+//
+// Возвращает false, если код уже был использован, в том числе параллельным запросом, или истек.
+func (s Repository) Consume(id int32) (bool, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return false, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("UPDATE AUTHORIZATION_CODES SET USED_AT = NOW() WHERE ID = %d AND USED_AT IS NULL AND EXPIRES_AT > NOW()", id)
+
+	result, err := db.Exec(sql)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func scan(row interface{ Scan(dest ...any) error }) (*AuthorizationCode, error) {
+	result := &AuthorizationCode{}
+	err := row.Scan(&result.Id, &result.ClientId, &result.UserId, &result.CodeHash, &result.RedirectUri, &result.CodeChallenge, &result.Scope,
+		pq.Array(&result.Amr), &result.Nonce, &result.AuthTime, &result.ExpiresAt, &result.UsedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -1,8 +1,12 @@
 package auths
 
 import (
+	"database/sql"
 	"fmt"
 	"goauth/data"
+	"time"
+
+	"github.com/lib/pq"
 )
 
 // Проекция таблицы AUTHS.
@@ -13,8 +17,44 @@ type Auth struct {
 	// Идентификатор пользователя, которому была выдана пара токенов.
 	UserId int32
 
-	// BCRYPT хэш от REFRESH токена.
+	// Хэш от REFRESH токена: дайджест HMAC-SHA256 или BCRYPT хэш для записей, созданных до перехода на дайджесты.
 	RefreshTokenHash string
+
+	// IP адрес пользователя, под которым тот получил пару токенов.
+	UserIp string
+
+	// Момент времени, до которого REFRESH токен считается действительным.
+	ExpiresAt time.Time
+
+	// Идентификатор записи, REFRESH токен которой был обменян на пару токенов этой записи. 0 для записи, созданной при аутентификации.
+	ParentId int32
+
+	// Идентификатор семейства записей: идентификатор записи, созданной при аутентификации, от которой происходит эта запись.
+	FamilyId int32
+
+	// Момент времени, когда REFRESH токен был обменян на новую пару токенов. nil, если токен еще не использован.
+	ConsumedAt *time.Time
+
+	// Название устройства, указанное пользователем при аутентификации.
+	DeviceName string
+
+	// Значение заголовка User-Agent запроса, в ответ на который была выдана пара токенов.
+	UserAgent string
+
+	// Момент времени начала сеанса: аутентификации, от которой происходит запись.
+	CreatedAt time.Time
+
+	// Момент времени последнего использования сеанса: выдачи пары токенов этой записи.
+	LastUsedAt time.Time
+
+	// Способы аутентификации, использованные при начале сеанса (RFC 8176).
+	Amr []string
+
+	// Идентификатор клиента OAuth 2.0, которому была выдана пара токенов. Пустая строка для токенов, выданных сервисом напрямую.
+	ClientId string
+
+	// Области доступа ACCESS токена, выданного вместе с REFRESH токеном, разделенные пробелами.
+	Scope string
 }
 
 // Репозиторий таблицы AUTHS.
@@ -23,6 +63,12 @@ type Repository struct {
 	Context data.Context
 }
 
+// Столбцы таблицы AUTHS в порядке их чтения.
+const columns = "ID, USER_ID, REFRESH_TOKEN_HASH, USER_IP, EXPIRES_AT, COALESCE(PARENT_ID, 0), COALESCE(FAMILY_ID, ID), CONSUMED_AT, DEVICE_NAME, USER_AGENT, CREATED_AT, LAST_USED_AT, AMR, CLIENT_ID, SCOPE"
+
+// Условие, которому удовлетворяют записи активных сеансов: последние записи семейств, REFRESH токены которых еще не использованы и не истекли.
+const activeCondition = "CONSUMED_AT IS NULL AND EXPIRES_AT > NOW()"
+
 // Получить запись из таблицы AUTHS по идентификатору.
 func (s Repository) Get(id int32) (*Auth, error) {
 	db, err := s.Context.Open()
@@ -32,55 +78,94 @@ func (s Repository) Get(id int32) (*Auth, error) {
 
 	defer db.Close()
 
-	sql := fmt.Sprintf("SELECT * FROM AUTHS WHERE ID = %d", id)
+	sql := fmt.Sprintf("SELECT %s FROM AUTHS WHERE ID = %d", columns, id)
 
 	row := db.QueryRow(sql)
 
-	result := &Auth{}
-	err = row.Scan(&result.Id, &result.UserId, &result.RefreshTokenHash)
-	if err != nil {
-		return nil, err
-	}
+	return scan(row)
+}
 
-	return result, nil
+// Удалить из таблицы AUTHS запись с указанным идентификатором. Возвращает удаленные записи.
+func (s Repository) Delete(id int32) ([]Auth, error) {
+	return s.delete(fmt.Sprintf("ID = %d", id))
+}
+
+// Удалить из таблицы AUTHS записи для указанных пользователей. Возвращает удаленные записи.
+func (s Repository) DeleteByUser(userId int32) ([]Auth, error) {
+	return s.delete(fmt.Sprintf("USER_ID = %d", userId))
+}
+
+// Удалить из таблицы AUTHS записи, выданные указанному клиенту OAuth 2.0. Возвращает удаленные записи.
+func (s Repository) DeleteByClient(clientId string) ([]Auth, error) {
+	return s.delete("CLIENT_ID = $1", clientId)
 }
 
-// Удалить из таблицы AUTHS запись с указанным идентификатором.
-func (s Repository) Delete(id int32) error {
+// Получить записи активных сеансов указанного пользователя, начиная с самого старого сеанса.
+func (s Repository) ListActiveByUser(userId int32) ([]Auth, error) {
 	db, err := s.Context.Open()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	defer db.Close()
 
-	sql := fmt.Sprintf("DELETE FROM AUTHS WHERE ID = %d", id)
+	sql := fmt.Sprintf("SELECT %s FROM AUTHS WHERE USER_ID = %d AND %s ORDER BY CREATED_AT, ID", columns, userId, activeCondition)
 
-	_, err = db.Exec(sql)
+	rows, err := db.Query(sql)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return scanAll(rows)
 }
 
-// Удалить из таблицы AUTHS записи для указанных пользователей
-func (s Repository) DeleteByUser(userId int32) error {
+// Удалить из таблицы AUTHS записи указанного пользователя, REFRESH токены которых истекли. Возвращает удаленные записи.
+func (s Repository) DeleteExpiredByUser(userId int32) ([]Auth, error) {
+	return s.delete(fmt.Sprintf("USER_ID = %d AND EXPIRES_AT <= NOW()", userId))
+}
+
+// Удалить из таблицы AUTHS все записи указанного семейства. Возвращает удаленные записи.
+func (s Repository) DeleteByFamily(familyId int32) ([]Auth, error) {
+	return s.delete(fmt.Sprintf("COALESCE(FAMILY_ID, ID) = %d", familyId))
+}
+
+// Удалить из таблицы AUTHS записи сеанса (семейства) указанного пользователя. Возвращает удаленные записи.
+//
+// Возвращает пустой срез, если у пользователя нет такого сеанса.
+func (s Repository) DeleteSession(userId int32, familyId int32) ([]Auth, error) {
+	return s.delete(fmt.Sprintf("USER_ID = %d AND COALESCE(FAMILY_ID, ID) = %d", userId, familyId))
+}
+
+// Удалить из таблицы AUTHS записи всех сеансов указанного пользователя, кроме сеанса (семейства) с указанным идентификатором.
+// Возвращает удаленные записи.
+func (s Repository) DeleteOtherSessions(userId int32, familyId int32) ([]Auth, error) {
+	return s.delete(fmt.Sprintf("USER_ID = %d AND COALESCE(FAMILY_ID, ID) <> %d", userId, familyId))
+}
+
+// Отметить REFRESH токен записи с указанным идентификатором как использованный.
+//
+// Возвращает false, если токен уже был использован ранее, в том числе параллельным запросом.
+func (s Repository) Consume(id int32) (bool, error) {
 	db, err := s.Context.Open()
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	defer db.Close()
 
-	sql := fmt.Sprintf("DELETE FROM AUTHS WHERE USER_ID = %d", userId)
+	sql := fmt.Sprintf("UPDATE AUTHS SET CONSUMED_AT = NOW() WHERE ID = %d AND CONSUMED_AT IS NULL", id)
 
-	_, err = db.Exec(sql)
+	result, err := db.Exec(sql)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	return nil
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
 }
 
 // Создать в таблице AUTHS запись.
@@ -92,17 +177,12 @@ func (s Repository) Create(t Auth) (*Auth, error) {
 
 	defer db.Close()
 
-	sql := fmt.Sprintf("INSERT INTO AUTHS (USER_ID, REFRESH_TOKEN_HASH) VALUES (%d, '%s') RETURNING *", t.UserId, t.RefreshTokenHash)
-
-	row := db.QueryRow(sql)
+	sql := fmt.Sprintf("INSERT INTO AUTHS (USER_ID, REFRESH_TOKEN_HASH, USER_IP, EXPIRES_AT, PARENT_ID, FAMILY_ID, DEVICE_NAME, USER_AGENT, CREATED_AT, LAST_USED_AT, AMR, CLIENT_ID, SCOPE) "+
+		"VALUES ($1, $2, $3, $4, NULLIF($5, 0), NULLIF($6, 0), $7, $8, $9, $10, $11, $12, $13) RETURNING %s", columns)
 
-	result := &Auth{}
-	err = row.Scan(&result.Id, &result.UserId, &result.RefreshTokenHash)
-	if err != nil {
-		return nil, err
-	}
+	row := db.QueryRow(sql, t.UserId, t.RefreshTokenHash, t.UserIp, t.ExpiresAt, t.ParentId, t.FamilyId, t.DeviceName, t.UserAgent, t.CreatedAt, t.LastUsedAt, pq.Array(t.Amr), t.ClientId, t.Scope)
 
-	return result, nil
+	return scan(row)
 }
 
 // Обновить запись в таблице AUTHS.
@@ -114,12 +194,58 @@ func (s Repository) Update(t Auth) error {
 
 	defer db.Close()
 
-	sql := fmt.Sprintf("UPDATE AUTHS SET USER_ID = %d, REFRESH_TOKEN_HASH = '%s' WHERE ID = %d", t.UserId, t.RefreshTokenHash, t.Id)
+	sql := "UPDATE AUTHS SET USER_ID = $1, REFRESH_TOKEN_HASH = $2, USER_IP = $3, EXPIRES_AT = $4 WHERE ID = $5"
 
-	_, err = db.Exec(sql)
+	_, err = db.Exec(sql, t.UserId, t.RefreshTokenHash, t.UserIp, t.ExpiresAt, t.Id)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// Удалить из таблицы AUTHS записи, удовлетворяющие условию, и вернуть их.
+func (s Repository) delete(condition string, args ...any) ([]Auth, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("DELETE FROM AUTHS WHERE %s RETURNING %s", condition, columns)
+
+	rows, err := db.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAll(rows)
+}
+
+func scanAll(rows *sql.Rows) ([]Auth, error) {
+	defer rows.Close()
+
+	result := []Auth{}
+	for rows.Next() {
+		auth, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, *auth)
+	}
+
+	return result, rows.Err()
+}
+
+func scan(row interface{ Scan(dest ...any) error }) (*Auth, error) {
+	result := &Auth{}
+	err := row.Scan(&result.Id, &result.UserId, &result.RefreshTokenHash, &result.UserIp, &result.ExpiresAt, &result.ParentId, &result.FamilyId, &result.ConsumedAt,
+		&result.DeviceName, &result.UserAgent, &result.CreatedAt, &result.LastUsedAt, pq.Array(&result.Amr), &result.ClientId, &result.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,178 @@
+package clients
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"goauth/data"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Клиент с таким идентификатором уже зарегистрирован.
+var ErrClientIdTaken = errors.New("the client id is already taken")
+
+// Проекция таблицы CLIENTS.
+type Client struct {
+	// Идентификатор клиента OAuth 2.0.
+	Id string
+
+	// Название клиента.
+	Name string
+
+	// Дайджест HMAC-SHA256 от секрета клиента. Пустая строка для публичных клиентов, которые не могут хранить секрет.
+	SecretHash string
+
+	// Гранты, которые разрешено использовать клиенту, например, "authorization_code" и "client_credentials".
+	Grants []string
+
+	// Области доступа, которые разрешено запрашивать клиенту.
+	Scopes []string
+
+	// Адреса перенаправления для гранта authorization_code. Сравниваются с адресом из запроса посимвольно.
+	RedirectUris []string
+
+	// Момент времени регистрации клиента.
+	CreatedAt time.Time
+}
+
+// Репозиторий таблицы CLIENTS.
+type Repository struct {
+	// Контекст подключения к БД.
+	Context data.Context
+}
+
+// Столбцы таблицы CLIENTS в порядке их чтения.
+const columns = "ID, NAME, COALESCE(SECRET_HASH, ''), GRANTS, SCOPES, REDIRECT_URIS, CREATED_AT"
+
+// Получить клиента по идентификатору.
+func (s Repository) Get(id string) (*Client, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("SELECT %s FROM CLIENTS WHERE ID = $1", columns)
+
+	row := db.QueryRow(sql, id)
+
+	return scan(row)
+}
+
+// Получить всех клиентов в порядке их регистрации.
+func (s Repository) List() ([]Client, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("SELECT %s FROM CLIENTS ORDER BY CREATED_AT, ID", columns)
+
+	rows, err := db.Query(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAll(rows)
+}
+
+// Зарегистрировать клиента.
+//
+// Возвращает ErrClientIdTaken, если клиент с таким идентификатором уже зарегистрирован.
+func (s Repository) Create(t Client) (*Client, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("INSERT INTO CLIENTS (ID, NAME, SECRET_HASH, GRANTS, SCOPES, REDIRECT_URIS) VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6) RETURNING %s", columns)
+
+	row := db.QueryRow(sql, t.Id, t.Name, t.SecretHash, pq.Array(t.Grants), pq.Array(t.Scopes), pq.Array(t.RedirectUris))
+
+	result, err := scan(row)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return nil, ErrClientIdTaken
+	}
+
+	return result, err
+}
+
+// Обновить название, гранты, области доступа и адреса перенаправления клиента.
+//
+// Возвращает false, если клиент не найден.
+func (s Repository) Update(t Client) (bool, error) {
+	return s.exec("UPDATE CLIENTS SET NAME = $2, GRANTS = $3, SCOPES = $4, REDIRECT_URIS = $5 WHERE ID = $1",
+		t.Id, t.Name, pq.Array(t.Grants), pq.Array(t.Scopes), pq.Array(t.RedirectUris))
+}
+
+// Обновить дайджест секрета клиента.
+//
+// Возвращает false, если клиент не найден.
+func (s Repository) UpdateSecretHash(id string, secretHash string) (bool, error) {
+	return s.exec("UPDATE CLIENTS SET SECRET_HASH = NULLIF($2, '') WHERE ID = $1", id, secretHash)
+}
+
+// Удалить клиента.
+//
+// Возвращает false, если клиент не найден.
+func (s Repository) Delete(id string) (bool, error) {
+	return s.exec("DELETE FROM CLIENTS WHERE ID = $1", id)
+}
+
+// Выполнить запрос на изменение и проверить, что он затронул запись.
+func (s Repository) exec(sql string, args ...any) (bool, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return false, err
+	}
+
+	defer db.Close()
+
+	result, err := db.Exec(sql, args...)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func scanAll(rows *sql.Rows) ([]Client, error) {
+	defer rows.Close()
+
+	result := []Client{}
+	for rows.Next() {
+		client, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, *client)
+	}
+
+	return result, rows.Err()
+}
+
+func scan(row interface{ Scan(dest ...any) error }) (*Client, error) {
+	result := &Client{}
+	err := row.Scan(&result.Id, &result.Name, &result.SecretHash, pq.Array(&result.Grants), pq.Array(&result.Scopes), pq.Array(&result.RedirectUris),
+		&result.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
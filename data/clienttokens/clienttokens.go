@@ -0,0 +1,122 @@
+package clienttokens
+
+import (
+	"database/sql"
+	"fmt"
+	"goauth/data"
+	"time"
+)
+
+// Проекция таблицы CLIENT_TOKENS.
+type ClientToken struct {
+	// Идентификатор ACCESS токена (`jti`). Выдается из той же последовательности, что и идентификаторы записей таблицы AUTHS,
+	// поэтому не совпадает с идентификаторами ACCESS токенов пользователей в хранилище отозванных токенов.
+	Id int32
+
+	// Идентификатор клиента OAuth 2.0, которому выдан токен.
+	ClientId string
+
+	// Момент времени, до которого токен считается действительным.
+	ExpiresAt time.Time
+}
+
+// Репозиторий таблицы CLIENT_TOKENS.
+type Repository struct {
+	// Контекст подключения к БД.
+	Context data.Context
+}
+
+// Столбцы таблицы CLIENT_TOKENS в порядке их чтения.
+const columns = "ID, CLIENT_ID, EXPIRES_AT"
+
+// Получить сведения о токене клиента по идентификатору.
+func (s Repository) Get(id int32) (*ClientToken, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("SELECT %s FROM CLIENT_TOKENS WHERE ID = %d", columns, id)
+
+	row := db.QueryRow(sql)
+
+	return scan(row)
+}
+
+// Создать запись о токене клиента. Записи о токенах с истекшим сроком действия при этом удаляются.
+func (s Repository) Create(t ClientToken) (*ClientToken, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	_, err = db.Exec("DELETE FROM CLIENT_TOKENS WHERE EXPIRES_AT <= NOW()")
+	if err != nil {
+		return nil, err
+	}
+
+	sql := fmt.Sprintf("INSERT INTO CLIENT_TOKENS (CLIENT_ID, EXPIRES_AT) VALUES ($1, $2) RETURNING %s", columns)
+
+	row := db.QueryRow(sql, t.ClientId, t.ExpiresAt)
+
+	return scan(row)
+}
+
+// Удалить запись о токене с указанным идентификатором. Возвращает удаленные записи.
+func (s Repository) Delete(id int32) ([]ClientToken, error) {
+	return s.delete("ID = $1", id)
+}
+
+// Удалить записи о токенах указанного клиента. Возвращает удаленные записи.
+func (s Repository) DeleteByClient(clientId string) ([]ClientToken, error) {
+	return s.delete("CLIENT_ID = $1", clientId)
+}
+
+// Удалить из таблицы CLIENT_TOKENS записи, удовлетворяющие условию, и вернуть их.
+func (s Repository) delete(condition string, args ...any) ([]ClientToken, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("DELETE FROM CLIENT_TOKENS WHERE %s RETURNING %s", condition, columns)
+
+	rows, err := db.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAll(rows)
+}
+
+func scanAll(rows *sql.Rows) ([]ClientToken, error) {
+	defer rows.Close()
+
+	result := []ClientToken{}
+	for rows.Next() {
+		token, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, *token)
+	}
+
+	return result, rows.Err()
+}
+
+func scan(row interface{ Scan(dest ...any) error }) (*ClientToken, error) {
+	result := &ClientToken{}
+	err := row.Scan(&result.Id, &result.ClientId, &result.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
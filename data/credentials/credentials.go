@@ -0,0 +1,163 @@
+package credentials
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"goauth/data"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Учетные данные с таким идентификатором уже зарегистрированы.
+var ErrCredentialTaken = errors.New("the credential is already registered")
+
+// Проекция таблицы WEBAUTHN_CREDENTIALS.
+type Credential struct {
+	// Идентификатор записи.
+	Id int32
+
+	// Идентификатор пользователя, которому принадлежат учетные данные.
+	UserId int32
+
+	// Идентификатор учетных данных, назначенный аутентификатором.
+	CredentialId []byte
+
+	// Открытый ключ учетных данных в формате COSE.
+	PublicKey []byte
+
+	// Последнее принятое значение счетчика подписей аутентификатора.
+	SignCount int64
+
+	// Название учетных данных, указанное пользователем при регистрации.
+	Name string
+
+	// Момент времени регистрации учетных данных.
+	CreatedAt time.Time
+
+	// Момент времени последней аутентификации учетными данными. nil, если они еще не использовались.
+	LastUsedAt *time.Time
+}
+
+// Репозиторий таблицы WEBAUTHN_CREDENTIALS.
+type Repository struct {
+	// Контекст подключения к БД.
+	Context data.Context
+}
+
+// Столбцы таблицы WEBAUTHN_CREDENTIALS в порядке их чтения.
+const columns = "ID, USER_ID, CREDENTIAL_ID, PUBLIC_KEY, SIGN_COUNT, NAME, CREATED_AT, LAST_USED_AT"
+
+// Получить учетные данные по идентификатору, назначенному аутентификатором.
+func (s Repository) GetByCredentialId(credentialId []byte) (*Credential, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("SELECT %s FROM WEBAUTHN_CREDENTIALS WHERE CREDENTIAL_ID = $1", columns)
+
+	row := db.QueryRow(sql, credentialId)
+
+	return scan(row)
+}
+
+// Получить учетные данные указанного пользователя в порядке их регистрации.
+func (s Repository) ListByUser(userId int32) ([]Credential, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("SELECT %s FROM WEBAUTHN_CREDENTIALS WHERE USER_ID = %d ORDER BY CREATED_AT, ID", columns, userId)
+
+	rows, err := db.Query(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAll(rows)
+}
+
+// Создать учетные данные.
+//
+// Возвращает ErrCredentialTaken, если учетные данные с таким идентификатором уже зарегистрированы.
+func (s Repository) Create(t Credential) (*Credential, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("INSERT INTO WEBAUTHN_CREDENTIALS (USER_ID, CREDENTIAL_ID, PUBLIC_KEY, SIGN_COUNT, NAME) VALUES ($1, $2, $3, $4, $5) RETURNING %s", columns)
+
+	row := db.QueryRow(sql, t.UserId, t.CredentialId, t.PublicKey, t.SignCount, t.Name)
+
+	result, err := scan(row)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return nil, ErrCredentialTaken
+	}
+
+	return result, err
+}
+
+// Сохранить новое значение счетчика подписей и отметить учетные данные как использованные.
+//
+// Возвращает false, если сохраненное значение счетчика уже не меньше указанного, в том числе из-за параллельного запроса.
+// Нулевые значения счетчика допускаются повторно: аутентификаторы, не ведущие счетчик, всегда возвращают 0.
+func (s Repository) UpdateSignCount(id int32, signCount int64) (bool, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return false, err
+	}
+
+	defer db.Close()
+
+	sql := "UPDATE WEBAUTHN_CREDENTIALS SET SIGN_COUNT = $2, LAST_USED_AT = NOW() WHERE ID = $1 AND (SIGN_COUNT < $2 OR SIGN_COUNT = 0 AND $2 = 0)"
+
+	result, err := db.Exec(sql, id, signCount)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func scanAll(rows *sql.Rows) ([]Credential, error) {
+	defer rows.Close()
+
+	result := []Credential{}
+	for rows.Next() {
+		credential, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, *credential)
+	}
+
+	return result, rows.Err()
+}
+
+func scan(row interface{ Scan(dest ...any) error }) (*Credential, error) {
+	result := &Credential{}
+	err := row.Scan(&result.Id, &result.UserId, &result.CredentialId, &result.PublicKey, &result.SignCount, &result.Name, &result.CreatedAt, &result.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,121 @@
+package magiclinks
+
+import (
+	"fmt"
+	"goauth/data"
+	"time"
+)
+
+// Проекция таблицы MAGIC_LINKS.
+type MagicLink struct {
+	// Идентификатор ссылки для входа.
+	Id int32
+
+	// Идентификатор пользователя, которому отправлена ссылка.
+	UserId int32
+
+	// Дайджест HMAC-SHA256 от одноразового значения (nonce), сохраненного в браузере, из которого запрошена ссылка.
+	NonceHash string
+
+	// Момент времени, до которого ссылка считается действительной.
+	ExpiresAt time.Time
+
+	// Момент времени, когда ссылка была использована. nil, если ссылка еще не использована.
+	UsedAt *time.Time
+}
+
+// Репозиторий таблицы MAGIC_LINKS.
+type Repository struct {
+	// Контекст подключения к БД.
+	Context data.Context
+}
+
+// Столбцы таблицы MAGIC_LINKS в порядке их чтения.
+const columns = "ID, USER_ID, NONCE_HASH, EXPIRES_AT, USED_AT"
+
+// Получить ссылку для входа по идентификатору.
+func (s Repository) Get(id int32) (*MagicLink, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("SELECT %s FROM MAGIC_LINKS WHERE ID = %d", columns, id)
+
+	row := db.QueryRow(sql)
+
+	return scan(row)
+}
+
+// Создать ссылку для входа.
+func (s Repository) Create(t MagicLink) (*MagicLink, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("INSERT INTO MAGIC_LINKS (USER_ID, NONCE_HASH, EXPIRES_AT) VALUES ($1, $2, $3) RETURNING %s", columns)
+
+	row := db.QueryRow(sql, t.UserId, t.NonceHash, t.ExpiresAt)
+
+	return scan(row)
+}
+
+// Отметить ссылку для входа как использованную.
+//
+// Возвращает false, если ссылка уже была использована, в том числе параллельным запросом, или истекла.
+func (s Repository) Consume(id int32) (bool, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return false, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("UPDATE MAGIC_LINKS SET USED_AT = NOW() WHERE ID = %d AND USED_AT IS NULL AND EXPIRES_AT > NOW()", id)
+
+	result, err := db.Exec(sql)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// Удалить ссылки для входа указанного пользователя.
+func (s Repository) DeleteByUser(userId int32) error {
+	db, err := s.Context.Open()
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("DELETE FROM MAGIC_LINKS WHERE USER_ID = %d", userId)
+
+	_, err = db.Exec(sql)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scan(row interface{ Scan(dest ...any) error }) (*MagicLink, error) {
+	result := &MagicLink{}
+	err := row.Scan(&result.Id, &result.UserId, &result.NonceHash, &result.ExpiresAt, &result.UsedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
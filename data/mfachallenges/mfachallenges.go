@@ -0,0 +1,170 @@
+package mfachallenges
+
+import (
+	"fmt"
+	"goauth/data"
+	"time"
+)
+
+// Проекция таблицы MFA_CHALLENGES.
+type MfaChallenge struct {
+	// Идентификатор второго шага аутентификации.
+	Id int32
+
+	// Идентификатор пользователя, прошедшего первый шаг аутентификации.
+	UserId int32
+
+	// Количество попыток предъявить второй фактор.
+	Attempts int32
+
+	// Момент времени, когда был пройден первый шаг аутентификации.
+	CreatedAt time.Time
+
+	// Момент времени, до которого второй шаг может быть завершен.
+	ExpiresAt time.Time
+
+	// Момент времени, когда второй шаг был завершен. nil, если второй шаг еще не завершен.
+	UsedAt *time.Time
+}
+
+// Репозиторий таблицы MFA_CHALLENGES.
+type Repository struct {
+	// Контекст подключения к БД.
+	Context data.Context
+}
+
+// Столбцы таблицы MFA_CHALLENGES в порядке их чтения.
+const columns = "ID, USER_ID, ATTEMPTS, CREATED_AT, EXPIRES_AT, USED_AT"
+
+// Получить второй шаг аутентификации по идентификатору.
+func (s Repository) Get(id int32) (*MfaChallenge, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("SELECT %s FROM MFA_CHALLENGES WHERE ID = %d", columns, id)
+
+	row := db.QueryRow(sql)
+
+	return scan(row)
+}
+
+// Создать второй шаг аутентификации.
+func (s Repository) Create(t MfaChallenge) (*MfaChallenge, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("INSERT INTO MFA_CHALLENGES (USER_ID, EXPIRES_AT) VALUES ($1, $2) RETURNING %s", columns)
+
+	row := db.QueryRow(sql, t.UserId, t.ExpiresAt)
+
+	return scan(row)
+}
+
+// Учесть попытку предъявить второй фактор.
+//
+// Возвращает false, если второй шаг уже завершен, истек или исчерпал maxAttempts попыток, в том числе из-за параллельных запросов.
+func (s Repository) Attempt(id int32, maxAttempts int32) (bool, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return false, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("UPDATE MFA_CHALLENGES SET ATTEMPTS = ATTEMPTS + 1 WHERE ID = %d AND USED_AT IS NULL AND EXPIRES_AT > NOW() AND ATTEMPTS < %d", id, maxAttempts)
+
+	result, err := db.Exec(sql)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// Отметить второй шаг аутентификации как завершенный.
+//
+// Возвращает false, если второй шаг уже был завершен, в том числе параллельным запросом, или истек.
+func (s Repository) Consume(id int32) (bool, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return false, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("UPDATE MFA_CHALLENGES SET USED_AT = NOW() WHERE ID = %d AND USED_AT IS NULL AND EXPIRES_AT > NOW()", id)
+
+	result, err := db.Exec(sql)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// Получить количество попыток предъявить второй фактор, сделанных пользователем во вторых шагах, созданных после указанного момента.
+func (s Repository) CountAttemptsSince(userId int32, since time.Time) (int32, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return 0, err
+	}
+
+	defer db.Close()
+
+	sql := "SELECT COALESCE(SUM(ATTEMPTS), 0) FROM MFA_CHALLENGES WHERE USER_ID = $1 AND CREATED_AT > $2"
+
+	var attempts int32
+	err = db.QueryRow(sql, userId, since).Scan(&attempts)
+	if err != nil {
+		return 0, err
+	}
+
+	return attempts, nil
+}
+
+// Удалить вторые шаги аутентификации пользователя, созданные до указанного момента.
+func (s Repository) DeleteCreatedBefore(userId int32, before time.Time) error {
+	db, err := s.Context.Open()
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	sql := "DELETE FROM MFA_CHALLENGES WHERE USER_ID = $1 AND CREATED_AT < $2"
+
+	_, err = db.Exec(sql, userId, before)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scan(row interface{ Scan(dest ...any) error }) (*MfaChallenge, error) {
+	result := &MfaChallenge{}
+	err := row.Scan(&result.Id, &result.UserId, &result.Attempts, &result.CreatedAt, &result.ExpiresAt, &result.UsedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
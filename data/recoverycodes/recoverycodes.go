@@ -0,0 +1,69 @@
+package recoverycodes
+
+import (
+	"fmt"
+	"goauth/data"
+)
+
+// Репозиторий таблицы RECOVERY_CODES.
+type Repository struct {
+	// Контекст подключения к БД.
+	Context data.Context
+}
+
+// Заменить коды восстановления пользователя новыми кодами с указанными дайджестами.
+func (s Repository) Replace(userId int32, codeHashes []string) error {
+	db, err := s.Context.Open()
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	transaction, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer transaction.Rollback()
+
+	_, err = transaction.Exec(fmt.Sprintf("DELETE FROM RECOVERY_CODES WHERE USER_ID = %d", userId))
+	if err != nil {
+		return err
+	}
+
+	for _, codeHash := range codeHashes {
+		_, err = transaction.Exec("INSERT INTO RECOVERY_CODES (USER_ID, CODE_HASH) VALUES ($1, $2)", userId, codeHash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return transaction.Commit()
+}
+
+// Отметить код восстановления пользователя с указанным дайджестом как использованный.
+//
+// Возвращает false, если такого кода нет или он уже использован.
+func (s Repository) Use(userId int32, codeHash string) (bool, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return false, err
+	}
+
+	defer db.Close()
+
+	sql := "UPDATE RECOVERY_CODES SET USED_AT = NOW() WHERE USER_ID = $1 AND CODE_HASH = $2 AND USED_AT IS NULL"
+
+	result, err := db.Exec(sql, userId, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
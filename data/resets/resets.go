@@ -0,0 +1,138 @@
+package resets
+
+import (
+	"fmt"
+	"goauth/data"
+	"time"
+)
+
+// Проекция таблицы PASSWORD_RESETS.
+type PasswordReset struct {
+	// Идентификатор запроса на сброс пароля.
+	Id int32
+
+	// Идентификатор пользователя, пароль которого сбрасывается.
+	UserId int32
+
+	// Дайджест HMAC-SHA256 от токена сброса пароля.
+	TokenHash string
+
+	// Момент времени, до которого токен считается действительным.
+	ExpiresAt time.Time
+
+	// Момент времени, когда токен был использован. nil, если токен еще не использован.
+	UsedAt *time.Time
+}
+
+// Репозиторий таблицы PASSWORD_RESETS.
+type Repository struct {
+	// Контекст подключения к БД.
+	Context data.Context
+}
+
+// Столбцы таблицы PASSWORD_RESETS в порядке их чтения.
+const columns = "ID, USER_ID, TOKEN_HASH, EXPIRES_AT, USED_AT"
+
+// Получить запрос на сброс пароля по идентификатору.
+func (s Repository) Get(id int32) (*PasswordReset, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("SELECT %s FROM PASSWORD_RESETS WHERE ID = %d", columns, id)
+
+	row := db.QueryRow(sql)
+
+	return scan(row)
+}
+
+// Создать запрос на сброс пароля.
+func (s Repository) Create(t PasswordReset) (*PasswordReset, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("INSERT INTO PASSWORD_RESETS (USER_ID, TOKEN_HASH, EXPIRES_AT) VALUES ($1, $2, $3) RETURNING %s", columns)
+
+	row := db.QueryRow(sql, t.UserId, t.TokenHash, t.ExpiresAt)
+
+	return scan(row)
+}
+
+// Обновить дайджест токена запроса на сброс пароля.
+func (s Repository) UpdateTokenHash(id int32, tokenHash string) error {
+	db, err := s.Context.Open()
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	_, err = db.Exec("UPDATE PASSWORD_RESETS SET TOKEN_HASH = $1 WHERE ID = $2", tokenHash, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Отметить токен запроса на сброс пароля как использованный.
+//
+// Возвращает false, если токен уже был использован или истек.
+func (s Repository) Consume(id int32) (bool, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return false, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("UPDATE PASSWORD_RESETS SET USED_AT = NOW() WHERE ID = %d AND USED_AT IS NULL AND EXPIRES_AT > NOW()", id)
+
+	result, err := db.Exec(sql)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// Удалить запросы на сброс пароля указанного пользователя.
+func (s Repository) DeleteByUser(userId int32) error {
+	db, err := s.Context.Open()
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("DELETE FROM PASSWORD_RESETS WHERE USER_ID = %d", userId)
+
+	_, err = db.Exec(sql)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scan(row interface{ Scan(dest ...any) error }) (*PasswordReset, error) {
+	result := &PasswordReset{}
+	err := row.Scan(&result.Id, &result.UserId, &result.TokenHash, &result.ExpiresAt, &result.UsedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,114 @@
+package revocations
+
+import (
+	"fmt"
+	"goauth/data"
+	"sync"
+	"time"
+)
+
+// Хранилище отозванных ACCESS токенов.
+//
+// Токен хранится до окончания срока его действия, после чего он будет отклонен и без хранилища.
+type Store interface {
+	// Отозвать токен с указанным идентификатором (`jti`), действительный до указанного момента времени.
+	Revoke(tokenId int32, expiresAt time.Time) error
+
+	// Проверить, отозван ли токен с указанным идентификатором.
+	IsRevoked(tokenId int32) (bool, error)
+}
+
+// Хранилище отозванных токенов в памяти процесса.
+//
+// Подходит для единственного экземпляра сервиса: при перезапуске сведения об отозванных токенах теряются.
+type Memory struct {
+	mutex   sync.Mutex
+	entries map[int32]time.Time
+}
+
+// Создать пустое хранилище отозванных токенов в памяти процесса.
+func NewMemory() *Memory {
+	return &Memory{entries: map[int32]time.Time{}}
+}
+
+// Отозвать токен с указанным идентификатором, действительный до указанного момента времени.
+func (s *Memory) Revoke(tokenId int32, expiresAt time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	for id, entryExpiresAt := range s.entries {
+		if !now.Before(entryExpiresAt) {
+			delete(s.entries, id)
+		}
+	}
+
+	if expiresAt.After(s.entries[tokenId]) && now.Before(expiresAt) {
+		s.entries[tokenId] = expiresAt
+	}
+
+	return nil
+}
+
+// Проверить, отозван ли токен с указанным идентификатором.
+func (s *Memory) IsRevoked(tokenId int32) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	expiresAt, ok := s.entries[tokenId]
+
+	return ok && time.Now().Before(expiresAt), nil
+}
+
+// Репозиторий таблицы REVOKED_TOKENS.
+type Repository struct {
+	// Контекст подключения к БД.
+	Context data.Context
+}
+
+// Отозвать токен с указанным идентификатором, действительный до указанного момента времени.
+//
+// Попутно удаляет из таблицы записи о токенах, срок действия которых истек.
+func (s Repository) Revoke(tokenId int32, expiresAt time.Time) error {
+	db, err := s.Context.Open()
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	_, err = db.Exec("DELETE FROM REVOKED_TOKENS WHERE EXPIRES_AT <= NOW()")
+	if err != nil {
+		return err
+	}
+
+	sql := "INSERT INTO REVOKED_TOKENS (TOKEN_ID, EXPIRES_AT) VALUES ($1, $2) " +
+		"ON CONFLICT (TOKEN_ID) DO UPDATE SET EXPIRES_AT = GREATEST(REVOKED_TOKENS.EXPIRES_AT, EXCLUDED.EXPIRES_AT)"
+
+	_, err = db.Exec(sql, tokenId, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Проверить, отозван ли токен с указанным идентификатором.
+func (s Repository) IsRevoked(tokenId int32) (bool, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return false, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM REVOKED_TOKENS WHERE TOKEN_ID = %d AND EXPIRES_AT > NOW())", tokenId)
+
+	var result bool
+	err = db.QueryRow(sql).Scan(&result)
+	if err != nil {
+		return false, err
+	}
+
+	return result, nil
+}
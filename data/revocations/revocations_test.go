@@ -0,0 +1,77 @@
+package revocations
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryRevoke(t *testing.T) {
+	store := NewMemory()
+
+	err := store.Revoke(1, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revoked, err := store.IsRevoked(1)
+	if err != nil || !revoked {
+		t.Fatalf("IsRevoked() of a revoked token = %t, %v", revoked, err)
+	}
+
+	revoked, err = store.IsRevoked(2)
+	if err != nil || revoked {
+		t.Fatalf("IsRevoked() of another token = %t, %v", revoked, err)
+	}
+}
+
+func TestMemoryIgnoresExpiredTokens(t *testing.T) {
+	store := NewMemory()
+
+	err := store.Revoke(1, time.Now().Add(-time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revoked, _ := store.IsRevoked(1)
+	if revoked || len(store.entries) != 0 {
+		t.Fatal("Revoke() stored an already expired token")
+	}
+}
+
+func TestMemoryKeepsLatestExpiration(t *testing.T) {
+	store := NewMemory()
+	later := time.Now().Add(time.Hour)
+
+	_ = store.Revoke(1, later)
+	_ = store.Revoke(1, time.Now().Add(time.Minute))
+
+	if !store.entries[1].Equal(later) {
+		t.Fatalf("Revoke() shortened the expiration to %v", store.entries[1])
+	}
+}
+
+func TestMemoryPrunesExpiredEntries(t *testing.T) {
+	store := NewMemory()
+
+	// Записи, срок действия токенов которых истек после отзыва.
+	store.entries[1] = time.Now().Add(-time.Second)
+	store.entries[2] = time.Now().Add(-time.Hour)
+
+	revoked, _ := store.IsRevoked(1)
+	if revoked {
+		t.Fatal("IsRevoked() reported an expired entry as revoked")
+	}
+
+	err := store.Revoke(3, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(store.entries) != 1 {
+		t.Fatalf("Revoke() left %d entries, want 1", len(store.entries))
+	}
+
+	if _, ok := store.entries[3]; !ok {
+		t.Fatal("Revoke() pruned the new entry")
+	}
+}
@@ -1,10 +1,16 @@
 package users
 
 import (
+	"errors"
 	"fmt"
 	"goauth/data"
+
+	"github.com/lib/pq"
 )
 
+// Адрес электронной почты уже занят другим пользователем.
+var ErrEmailTaken = errors.New("the email is already taken")
+
 // Проекция таблицы USERS.
 type User struct {
 	// Идентификатор пользователя.
@@ -12,6 +18,27 @@ type User struct {
 
 	// Адрес электронной почты пользователя.
 	Email string
+
+	// Роли пользователя.
+	Roles []string
+
+	// Области доступа, которые указываются в ACCESS токенах, выданных пользователю сервисом напрямую.
+	Scopes []string
+
+	// Хэш пароля пользователя в формате PHC. Пустая строка, если пароль не задан.
+	PasswordHash string
+
+	// Признак подтвержденного адреса электронной почты.
+	EmailVerified bool
+
+	// Секрет TOTP в кодировке Base32. Пустая строка, если второй фактор не настраивался.
+	TotpSecret string
+
+	// Признак включенного второго фактора TOTP: секрет подтвержден первым кодом.
+	TotpEnabled bool
+
+	// Последний шаг времени, код которого был принят. Коды этого и предыдущих шагов повторно не принимаются.
+	TotpLastStep int64
 }
 
 // Репозиторий таблицы USERS.
@@ -20,6 +47,9 @@ type Repository struct {
 	Context data.Context
 }
 
+// Столбцы таблицы USERS в порядке их чтения.
+const columns = "ID, EMAIL, ROLES, SCOPES, COALESCE(PASSWORD_HASH, ''), EMAIL_VERIFIED, COALESCE(TOTP_SECRET, ''), TOTP_ENABLED, TOTP_LAST_STEP"
+
 // Получить пользователя по его идентификатору.
 func (s Repository) Get(id int32) (*User, error) {
 	db, err := s.Context.Open()
@@ -29,12 +59,144 @@ func (s Repository) Get(id int32) (*User, error) {
 
 	defer db.Close()
 
-	sql := fmt.Sprintf("SELECT * FROM USERS WHERE ID = %d", id)
+	sql := fmt.Sprintf("SELECT %s FROM USERS WHERE ID = %d", columns, id)
 
 	row := db.QueryRow(sql)
 
+	return scan(row)
+}
+
+// Получить пользователя по адресу электронной почты без учета регистра.
+func (s Repository) GetByEmail(email string) (*User, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("SELECT %s FROM USERS WHERE LOWER(EMAIL) = LOWER($1)", columns)
+
+	row := db.QueryRow(sql, email)
+
+	return scan(row)
+}
+
+// Создать пользователя с указанным адресом электронной почты и хэшем пароля. Адрес считается неподтвержденным.
+//
+// Возвращает ErrEmailTaken, если адрес уже занят.
+func (s Repository) Create(email string, passwordHash string) (*User, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("INSERT INTO USERS (EMAIL, PASSWORD_HASH) VALUES ($1, $2) RETURNING %s", columns)
+
+	row := db.QueryRow(sql, email, passwordHash)
+
+	result, err := scan(row)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return nil, ErrEmailTaken
+	}
+
+	return result, err
+}
+
+// Отметить адрес электронной почты пользователя как подтвержденный, если он совпадает с указанным.
+//
+// Возвращает false, если адрес уже подтвержден или был изменен.
+func (s Repository) MarkEmailVerified(id int32, email string) (bool, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return false, err
+	}
+
+	defer db.Close()
+
+	sql := "UPDATE USERS SET EMAIL_VERIFIED = TRUE WHERE ID = $1 AND LOWER(EMAIL) = LOWER($2) AND NOT EMAIL_VERIFIED"
+
+	result, err := db.Exec(sql, id, email)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// Обновить хэш пароля пользователя.
+func (s Repository) UpdatePasswordHash(id int32, passwordHash string) error {
+	db, err := s.Context.Open()
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	_, err = db.Exec("UPDATE USERS SET PASSWORD_HASH = $1 WHERE ID = $2", passwordHash, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Задать пользователю новый неподтвержденный секрет TOTP.
+//
+// Возвращает false, если второй фактор TOTP у пользователя уже включен.
+func (s Repository) UpdateTotpSecret(id int32, secret string) (bool, error) {
+	return s.update("UPDATE USERS SET TOTP_SECRET = $2, TOTP_ENABLED = FALSE, TOTP_LAST_STEP = 0 WHERE ID = $1 AND NOT TOTP_ENABLED", id, secret)
+}
+
+// Включить второй фактор TOTP, отметив шаг времени подтверждающего кода как использованный.
+//
+// Возвращает false, если второй фактор уже включен или секрет не задан.
+func (s Repository) EnableTotp(id int32, step int64) (bool, error) {
+	return s.update("UPDATE USERS SET TOTP_ENABLED = TRUE, TOTP_LAST_STEP = $2 WHERE ID = $1 AND NOT TOTP_ENABLED AND TOTP_SECRET IS NOT NULL", id, step)
+}
+
+// Отметить шаг времени кода TOTP как использованный.
+//
+// Возвращает false, если код этого или более позднего шага уже был принят.
+func (s Repository) UseTotpStep(id int32, step int64) (bool, error) {
+	return s.update("UPDATE USERS SET TOTP_LAST_STEP = $2 WHERE ID = $1 AND TOTP_ENABLED AND TOTP_LAST_STEP < $2", id, step)
+}
+
+// Выполнить запрос на обновление и проверить, что он затронул запись.
+func (s Repository) update(sql string, args ...any) (bool, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return false, err
+	}
+
+	defer db.Close()
+
+	result, err := db.Exec(sql, args...)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func scan(row interface{ Scan(dest ...any) error }) (*User, error) {
 	result := &User{}
-	err = row.Scan(&result.Id, &result.Email)
+	err := row.Scan(&result.Id, &result.Email, pq.Array(&result.Roles), pq.Array(&result.Scopes), &result.PasswordHash, &result.EmailVerified,
+		&result.TotpSecret, &result.TotpEnabled, &result.TotpLastStep)
 	if err != nil {
 		return nil, err
 	}
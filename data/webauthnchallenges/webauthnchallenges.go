@@ -0,0 +1,77 @@
+package webauthnchallenges
+
+import (
+	"fmt"
+	"goauth/data"
+	"time"
+)
+
+// Проекция таблицы WEBAUTHN_CHALLENGES.
+type WebAuthnChallenge struct {
+	// Идентификатор вызова.
+	Id int32
+
+	// Вызов (challenge), выданный браузеру при начале церемонии WebAuthn.
+	Challenge []byte
+
+	// Момент времени, до которого церемония может быть завершена.
+	ExpiresAt time.Time
+}
+
+// Репозиторий таблицы WEBAUTHN_CHALLENGES.
+type Repository struct {
+	// Контекст подключения к БД.
+	Context data.Context
+}
+
+// Столбцы таблицы WEBAUTHN_CHALLENGES в порядке их чтения.
+const columns = "ID, CHALLENGE, EXPIRES_AT"
+
+// Создать вызов. Вызовы с истекшим сроком действия при этом удаляются.
+func (s Repository) Create(t WebAuthnChallenge) (*WebAuthnChallenge, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	_, err = db.Exec("DELETE FROM WEBAUTHN_CHALLENGES WHERE EXPIRES_AT <= NOW()")
+	if err != nil {
+		return nil, err
+	}
+
+	sql := fmt.Sprintf("INSERT INTO WEBAUTHN_CHALLENGES (CHALLENGE, EXPIRES_AT) VALUES ($1, $2) RETURNING %s", columns)
+
+	row := db.QueryRow(sql, t.Challenge, t.ExpiresAt)
+
+	return scan(row)
+}
+
+// Использовать вызов: удалить его и вернуть удаленную запись.
+//
+// Возвращает sql.ErrNoRows, если вызов не найден, уже использован, в том числе параллельным запросом, или истек.
+func (s Repository) Consume(id int32) (*WebAuthnChallenge, error) {
+	db, err := s.Context.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	sql := fmt.Sprintf("DELETE FROM WEBAUTHN_CHALLENGES WHERE ID = %d AND EXPIRES_AT > NOW() RETURNING %s", id, columns)
+
+	row := db.QueryRow(sql)
+
+	return scan(row)
+}
+
+func scan(row interface{ Scan(dest ...any) error }) (*WebAuthnChallenge, error) {
+	result := &WebAuthnChallenge{}
+	err := row.Scan(&result.Id, &result.Challenge, &result.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -5,3 +5,5 @@ go 1.23.4
 require github.com/lib/pq v1.10.9
 
 require golang.org/x/crypto v0.31.0
+
+require golang.org/x/sys v0.28.0 // indirect
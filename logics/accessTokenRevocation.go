@@ -0,0 +1,84 @@
+package logics
+
+import (
+	"goauth/data/auths"
+	"goauth/data/clienttokens"
+	"goauth/logics/services"
+	"time"
+)
+
+// Команда на отзыв ACCESS токенов, выданных для удаленных или использованных записей таблицы AUTHS.
+type AccessTokenRevocationCommand struct {
+	// Удаленные или использованные записи таблицы AUTHS.
+	Auths []auths.Auth
+}
+
+// Обработчик команды на отзыв ACCESS токенов, выданных для удаленных записей таблицы AUTHS.
+//
+// ACCESS токен записи выдается одновременно с ней, поэтому отзываются только токены записей,
+// выданные не раньше, чем за время жизни ACCESS токена до текущего момента.
+type AccessTokenRevocationCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *AccessTokenRevocationCommand
+}
+
+// Обработать команду на отзыв ACCESS токенов, выданных для удаленных записей таблицы AUTHS.
+func (s *AccessTokenRevocationCommandHandler) Handle() {
+	now := time.Now()
+
+	for _, auth := range s.Command.Auths {
+		expiresAt := s.tokenExpiresAt(auth)
+		if !now.Before(expiresAt) {
+			continue
+		}
+
+		err := services.RevokedTokensStore().Revoke(auth.Id, expiresAt)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// 1-й уровень абстракции.
+
+func (s *AccessTokenRevocationCommandHandler) tokenExpiresAt(auth auths.Auth) time.Time {
+	return auth.LastUsedAt.Add(services.AccessTokenIssuer().Lifetime + services.Validator().Skew)
+}
+
+// Отозвать ACCESS токены удаленных записей таблицы AUTHS. Паникует, если удаление завершилось ошибкой.
+func revokeDeletedAuths(deletedAuths []auths.Auth, err error) []auths.Auth {
+	if err != nil {
+		panic(err)
+	}
+
+	handler := AccessTokenRevocationCommandHandler{
+		Command: &AccessTokenRevocationCommand{
+			Auths: deletedAuths,
+		},
+	}
+
+	handler.Handle()
+
+	return deletedAuths
+}
+
+// Отозвать ACCESS токены клиентов, записи о которых удалены из таблицы CLIENT_TOKENS. Паникует, если удаление завершилось ошибкой.
+func revokeDeletedClientTokens(deletedTokens []clienttokens.ClientToken, err error) {
+	if err != nil {
+		panic(err)
+	}
+
+	now := time.Now()
+
+	for _, token := range deletedTokens {
+		expiresAt := token.ExpiresAt.Add(services.Validator().Skew)
+		if !now.Before(expiresAt) {
+			continue
+		}
+
+		err := services.RevokedTokensStore().Revoke(token.Id, expiresAt)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package logics
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"goauth/data/auths"
+	"goauth/logics/services"
+	"goauth/tokens/access"
+	"goauth/tokens/jwt"
+)
+
+// Команда для проверки ACCESS токена, предъявленного пользователем.
+type AccessTokenVerificationCommand struct {
+	// ACCESS токен пользователя.
+	AccessToken string
+
+	// Принимать токены, выданные клиентам OAuth 2.0 от имени пользователя. Иначе принимаются только токены,
+	// выданные сервисом напрямую, так как токен клиента ограничен разрешенными ему областями доступа.
+	AllowClients bool
+}
+
+// Результат проверки ACCESS токена.
+type AccessTokenVerificationResult struct {
+	// Декодированный ACCESS токен.
+	Token *jwt.Jwt[access.AccessTokenPayload]
+
+	// Запись в таблице AUTHS, для которой был выдан токен.
+	Auth *auths.Auth
+}
+
+// Обработчик команды для проверки ACCESS токена, предъявленного пользователем.
+type AccessTokenVerificationCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *AccessTokenVerificationCommand
+
+	_token *jwt.Jwt[access.AccessTokenPayload]
+}
+
+// Обработать команду для проверки ACCESS токена, предъявленного пользователем.
+func (s *AccessTokenVerificationCommandHandler) Handle() *AccessTokenVerificationResult {
+	s.panicIfTokenIsIssuedToClient()
+	s.panicIfTokenIsRevoked()
+
+	return &AccessTokenVerificationResult{
+		Token: s.token(),
+		Auth:  s.getAuth(),
+	}
+}
+
+// 1-й уровень абстракции.
+
+func (s *AccessTokenVerificationCommandHandler) token() *jwt.Jwt[access.AccessTokenPayload] {
+	if s._token == nil {
+		s._token = s.decodeToken()
+	}
+
+	return s._token
+}
+
+func (s *AccessTokenVerificationCommandHandler) panicIfTokenIsIssuedToClient() {
+	if !s.Command.AllowClients && s.token().Payload.ClientId != "" {
+		panic(fmt.Errorf("%w: ACCESS token issued to an OAuth 2.0 client is not accepted here", ErrUnauthorized))
+	}
+}
+
+func (s *AccessTokenVerificationCommandHandler) panicIfTokenIsRevoked() {
+	revoked, err := services.RevokedTokensStore().IsRevoked(s.token().Payload.Id)
+	if err != nil {
+		panic(err)
+	}
+
+	if revoked {
+		panic(fmt.Errorf("%w: ACCESS token has been revoked", ErrUnauthorized))
+	}
+}
+
+func (s *AccessTokenVerificationCommandHandler) getAuth() *auths.Auth {
+	auth, err := services.AuthsRepository().Get(s.token().Payload.Id)
+	if errors.Is(err, sql.ErrNoRows) {
+		panic(fmt.Errorf("%w: the session of ACCESS token has been revoked", ErrUnauthorized))
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	return auth
+}
+
+// 2-й уровень абстракции.
+
+func (s *AccessTokenVerificationCommandHandler) decodeToken() *jwt.Jwt[access.AccessTokenPayload] {
+	if s.Command.AccessToken == "" {
+		panic(fmt.Errorf("%w: ACCESS token is not specified", ErrUnauthorized))
+	}
+
+	token, err := services.AccessTokenIssuer().Decode(s.Command.AccessToken)
+	if err != nil {
+		panic(fmt.Errorf("%w: %w", ErrUnauthorized, err))
+	}
+
+	return token
+}
+
+// Проверить ACCESS токен, выданный клиенту по гранту client_credentials: подпись, утверждения, отзыв
+// и наличие записи в таблице CLIENT_TOKENS. Паникует с ошибкой ErrUnauthorized, если токен недействителен.
+func verifyClientAccessToken(accessToken string) *jwt.Jwt[access.AccessTokenPayload] {
+	token, err := services.AccessTokenIssuer().Decode(accessToken)
+	if err != nil {
+		panic(fmt.Errorf("%w: %w", ErrUnauthorized, err))
+	}
+
+	payload := token.Payload
+	if payload.Id == 0 || payload.ClientId == "" || payload.Subject != payload.ClientId {
+		panic(fmt.Errorf("%w: ACCESS token is not issued by the %s grant", ErrUnauthorized, CLIENT_CREDENTIALS_GRANT))
+	}
+
+	revoked, err := services.RevokedTokensStore().IsRevoked(payload.Id)
+	if err != nil {
+		panic(err)
+	}
+
+	if revoked {
+		panic(fmt.Errorf("%w: ACCESS token has been revoked", ErrUnauthorized))
+	}
+
+	savedToken, err := services.ClientTokensRepository().Get(payload.Id)
+	if errors.Is(err, sql.ErrNoRows) {
+		panic(fmt.Errorf("%w: ACCESS token has been revoked", ErrUnauthorized))
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	if savedToken.ClientId != payload.ClientId {
+		panic(fmt.Errorf("%w: ACCESS token is issued to another client", ErrUnauthorized))
+	}
+
+	return token
+}
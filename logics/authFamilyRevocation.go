@@ -0,0 +1,76 @@
+package logics
+
+import (
+	"goauth/data/auths"
+	"goauth/data/users"
+	"goauth/logics/services"
+	"log"
+)
+
+// Команда на завершение сеанса, REFRESH токен которого был использован повторно.
+type AuthFamilyRevocationCommand struct {
+	// Запись в таблице AUTHS, REFRESH токен которой был использован повторно.
+	Auth *auths.Auth
+
+	// IP адрес, с которого был использован токен.
+	UserIp string
+}
+
+// Обработчик команды на завершение сеанса, REFRESH токен которого был использован повторно.
+type AuthFamilyRevocationCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *AuthFamilyRevocationCommand
+
+	_user *users.User
+}
+
+// Обработать команду на завершение сеанса, REFRESH токен которого был использован повторно.
+func (s *AuthFamilyRevocationCommandHandler) Handle() {
+	s.deleteAuthFamily()
+
+	s.logSecurityEvent()
+
+	s.createNotificationCommandHandler().Handle()
+}
+
+// 1-й уровень абстракции.
+
+func (s *AuthFamilyRevocationCommandHandler) deleteAuthFamily() {
+	revokeDeletedAuths(services.AuthsRepository().DeleteByFamily(s.Command.Auth.FamilyId))
+}
+
+func (s *AuthFamilyRevocationCommandHandler) logSecurityEvent() {
+	log.Printf("::: SECURITY: REFRESH token of AUTHS record %d has been reused from %s, family %d of user %d is revoked",
+		s.Command.Auth.Id, s.Command.UserIp, s.Command.Auth.FamilyId, s.Command.Auth.UserId)
+}
+
+func (s *AuthFamilyRevocationCommandHandler) createNotificationCommandHandler() *NotificationCommandHandler {
+	return &NotificationCommandHandler{
+		Command: &NotificationCommand{
+			ReceiverEmail:  s.user().Email,
+			MessageSubject: "(shumilija/goauth) WARNING",
+			MessageBody:    "Выполнена попытка повторного использования REFRESH токена, все связанные с ним сеансы завершены. IP адрес: " + s.Command.UserIp,
+		},
+	}
+}
+
+// 2-й уровень абстракции.
+
+func (s *AuthFamilyRevocationCommandHandler) user() *users.User {
+	if s._user == nil {
+		s._user = s.getUser()
+	}
+
+	return s._user
+}
+
+// 3-й уровень абстракции.
+
+func (s *AuthFamilyRevocationCommandHandler) getUser() *users.User {
+	user, err := services.UsersRepository().Get(s.Command.Auth.UserId)
+	if err != nil {
+		panic(err)
+	}
+
+	return user
+}
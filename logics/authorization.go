@@ -0,0 +1,292 @@
+package logics
+
+import (
+	"goauth/data/authorizationcodes"
+	"goauth/data/clients"
+	"goauth/logics/services"
+	"goauth/secrets"
+	"goauth/tokens/opaque"
+	"net/url"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// Параметры запроса на авторизацию OAuth 2.0 (RFC 6749, раздел 4.1.1) с вызовом PKCE (RFC 7636).
+type AuthorizationRequest struct {
+	// Тип ответа, всегда "code".
+	ResponseType string
+
+	// Идентификатор клиента.
+	ClientId string
+
+	// Адрес перенаправления клиента.
+	RedirectUri string
+
+	// Запрошенные области доступа, разделенные пробелами.
+	Scope string
+
+	// Значение, которое возвращается клиенту без изменений для защиты от CSRF.
+	State string
+
+	// Вызов PKCE: SHA-256 от секрета клиента в кодировке Base64URL.
+	CodeChallenge string
+
+	// Способ вычисления вызова PKCE, всегда "S256".
+	CodeChallengeMethod string
+
+	// Значение, которое возвращается клиенту в ID токене для защиты от его повторного использования (OpenID Connect).
+	Nonce string
+}
+
+// Допустимый вызов PKCE: 32 байта SHA-256 в кодировке Base64URL без выравнивания.
+var codeChallengePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{43}$`)
+
+// Запрос на авторизацию OAuth 2.0, поступивший из браузера пользователя.
+type AuthorizationQuery struct {
+	// Параметры запроса на авторизацию.
+	Request AuthorizationRequest
+}
+
+// Обработчик запроса на авторизацию OAuth 2.0, поступившего из браузера пользователя.
+//
+// Сервис не отображает страниц, поэтому после проверки параметров браузер перенаправляется на страницу авторизации
+// клиентского приложения, которая аутентифицирует пользователя и передает запрос с его ACCESS токеном.
+type AuthorizationQueryHandler struct {
+	// Обрабатываемый запрос.
+	Query *AuthorizationQuery
+}
+
+// Обработать запрос на авторизацию. Возвращает адрес, на который требуется перенаправить браузер.
+func (s *AuthorizationQueryHandler) Handle() string {
+	client := panicIfRedirectUriIsNotRegistered(&s.Query.Request)
+
+	err := validateAuthorizationRequest(&s.Query.Request, client)
+	if err != nil {
+		return authorizationRedirectUri(&s.Query.Request, authorizationErrorParameters(err))
+	}
+
+	return secrets.APPLICATION_URL + "/authorize?" + authorizationRequestParameters(&s.Query.Request).Encode()
+}
+
+// Команда на выдачу кода авторизации OAuth 2.0 аутентифицированному пользователю.
+type AuthorizationCommand struct {
+	// ACCESS токен пользователя.
+	AccessToken string
+
+	// Параметры запроса на авторизацию.
+	Request AuthorizationRequest
+}
+
+// Результат выдачи кода авторизации.
+type AuthorizationResult struct {
+	// Адрес перенаправления клиента с кодом авторизации или с кодом ошибки.
+	RedirectUri string
+}
+
+// Обработчик команды на выдачу кода авторизации OAuth 2.0 аутентифицированному пользователю.
+//
+// Код выдается на `services.AUTHORIZATION_CODE_LIFETIME`, обменивается на пару токенов один раз и хранится только в виде дайджеста.
+type AuthorizationCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *AuthorizationCommand
+
+	_client       *clients.Client
+	_verification *AccessTokenVerificationResult
+	_createdCode  *authorizationcodes.AuthorizationCode
+	_code         *string
+}
+
+// Обработать команду на выдачу кода авторизации.
+func (s *AuthorizationCommandHandler) Handle() *AuthorizationResult {
+	err := validateAuthorizationRequest(&s.Command.Request, s.client())
+	if err != nil {
+		return &AuthorizationResult{
+			RedirectUri: authorizationRedirectUri(&s.Command.Request, authorizationErrorParameters(err)),
+		}
+	}
+
+	s.verification()
+
+	s.saveCodeHash()
+
+	return s.result()
+}
+
+// 1-й уровень абстракции.
+
+func (s *AuthorizationCommandHandler) client() *clients.Client {
+	if s._client == nil {
+		s._client = panicIfRedirectUriIsNotRegistered(&s.Command.Request)
+	}
+
+	return s._client
+}
+
+func (s *AuthorizationCommandHandler) verification() *AccessTokenVerificationResult {
+	if s._verification == nil {
+		s._verification = verifyAccessToken(s.Command.AccessToken)
+	}
+
+	return s._verification
+}
+
+func (s *AuthorizationCommandHandler) saveCodeHash() {
+	err := services.AuthorizationCodesRepository().UpdateCodeHash(s.createdCode().Id, services.OneTimeTokenDigester().Digest(*s.code()))
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (s *AuthorizationCommandHandler) result() *AuthorizationResult {
+	parameters := url.Values{}
+	parameters.Set("code", *s.code())
+
+	return &AuthorizationResult{
+		RedirectUri: authorizationRedirectUri(&s.Command.Request, parameters),
+	}
+}
+
+// 2-й уровень абстракции.
+
+func (s *AuthorizationCommandHandler) code() *string {
+	if s._code == nil {
+		s._code = s.createCode()
+	}
+
+	return s._code
+}
+
+// 3-й уровень абстракции.
+
+func (s *AuthorizationCommandHandler) createCode() *string {
+	code, err := opaque.New(s.createdCode().Id)
+	if err != nil {
+		panic(err)
+	}
+
+	return &code
+}
+
+func (s *AuthorizationCommandHandler) createdCode() *authorizationcodes.AuthorizationCode {
+	if s._createdCode == nil {
+		s._createdCode = s.createAuthorizationCode()
+	}
+
+	return s._createdCode
+}
+
+// 4-й уровень абстракции.
+
+func (s *AuthorizationCommandHandler) createAuthorizationCode() *authorizationcodes.AuthorizationCode {
+	scope, _ := allowedScope(s.client(), s.Command.Request.Scope)
+
+	code, err := services.AuthorizationCodesRepository().Create(authorizationcodes.AuthorizationCode{
+		ClientId:      s.Command.Request.ClientId,
+		UserId:        s.verification().Auth.UserId,
+		RedirectUri:   s.Command.Request.RedirectUri,
+		CodeChallenge: s.Command.Request.CodeChallenge,
+		Scope:         scope,
+		Amr:           s.verification().Auth.Amr,
+		Nonce:         s.Command.Request.Nonce,
+		AuthTime:      s.verification().Auth.CreatedAt,
+		ExpiresAt:     time.Now().Add(services.AUTHORIZATION_CODE_LIFETIME),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return code
+}
+
+// Убедиться, что клиент зарегистрирован и адрес перенаправления в точности совпадает с одним из его адресов.
+//
+// Иначе браузер не перенаправляется клиенту, а пользователю возвращается ошибка, чтобы код нельзя было получить на чужой адрес.
+func panicIfRedirectUriIsNotRegistered(request *AuthorizationRequest) *clients.Client {
+	client := getClient(request.ClientId)
+	if client == nil {
+		panic(&OAuthError{Code: "invalid_request", Description: "the client is not registered", Err: ErrInvalidRequest})
+	}
+
+	if !slices.Contains(client.RedirectUris, request.RedirectUri) {
+		panic(&OAuthError{Code: "invalid_request", Description: "the redirect URI is not registered for the client", Err: ErrInvalidRequest})
+	}
+
+	return client
+}
+
+// Проверить параметры запроса на авторизацию, кроме клиента и адреса перенаправления.
+//
+// Возвращает ошибку, о которой клиент уведомляется через адрес перенаправления.
+func validateAuthorizationRequest(request *AuthorizationRequest, client *clients.Client) *OAuthError {
+	if request.ResponseType != "code" {
+		return &OAuthError{Code: "unsupported_response_type", Description: "only the authorization code flow is supported", Err: ErrInvalidRequest}
+	}
+
+	if !slices.Contains(client.Grants, AUTHORIZATION_CODE_GRANT) {
+		return &OAuthError{Code: "unauthorized_client", Description: "the client is not allowed to use the authorization code grant", Err: ErrInvalidRequest}
+	}
+
+	_, ok := allowedScope(client, request.Scope)
+	if !ok {
+		return &OAuthError{Code: "invalid_scope", Description: "the requested scope is not allowed for the client", Err: ErrInvalidRequest}
+	}
+
+	if scopeContains(request.Scope, OPENID_SCOPE) && !services.OPENID_CONNECT_IS_ENABLED {
+		return &OAuthError{Code: "invalid_scope", Description: "OpenID Connect is not configured", Err: ErrInvalidRequest}
+	}
+
+	if request.CodeChallengeMethod != "S256" || !codeChallengePattern.MatchString(request.CodeChallenge) {
+		return &OAuthError{Code: "invalid_request", Description: "PKCE with the S256 code challenge method is required", Err: ErrInvalidRequest}
+	}
+
+	return nil
+}
+
+// Получить параметры перенаправления, сообщающие клиенту об ошибке.
+func authorizationErrorParameters(err *OAuthError) url.Values {
+	parameters := url.Values{}
+	parameters.Set("error", err.Code)
+	parameters.Set("error_description", err.Description)
+
+	return parameters
+}
+
+// Получить адрес перенаправления клиента, дополненный указанными параметрами и значением state.
+func authorizationRedirectUri(request *AuthorizationRequest, parameters url.Values) string {
+	redirectUri, err := url.Parse(request.RedirectUri)
+	if err != nil {
+		panic(err)
+	}
+
+	if request.State != "" {
+		parameters.Set("state", request.State)
+	}
+
+	query := redirectUri.Query()
+	for name, values := range parameters {
+		query[name] = values
+	}
+
+	redirectUri.RawQuery = query.Encode()
+
+	return redirectUri.String()
+}
+
+// Получить параметры запроса на авторизацию в виде строки запроса.
+func authorizationRequestParameters(request *AuthorizationRequest) url.Values {
+	parameters := url.Values{}
+	parameters.Set("response_type", request.ResponseType)
+	parameters.Set("client_id", request.ClientId)
+	parameters.Set("redirect_uri", request.RedirectUri)
+	parameters.Set("scope", request.Scope)
+	parameters.Set("state", request.State)
+	parameters.Set("code_challenge", request.CodeChallenge)
+	parameters.Set("code_challenge_method", request.CodeChallengeMethod)
+
+	if request.Nonce != "" {
+		parameters.Set("nonce", request.Nonce)
+	}
+
+	return parameters
+}
@@ -0,0 +1,22 @@
+package logics
+
+import (
+	"log"
+)
+
+// Выполнить действие в фоне, не задерживая ответ пользователю.
+//
+// Используется там, где время ответа не должно зависеть от того, существует ли пользователь:
+// работа с базой данных и SMTP сервером выполняется после отправки ответа. Паника действия записывается в журнал.
+func runInBackground(description string, action func()) {
+	go func() {
+		defer func() {
+			recovered := recover()
+			if recovered != nil {
+				log.Printf("::: %s: %v", description, recovered)
+			}
+		}()
+
+		action()
+	}()
+}
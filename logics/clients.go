@@ -0,0 +1,453 @@
+package logics
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"goauth/data/clients"
+	"goauth/logics/services"
+	"goauth/secrets"
+	"goauth/tokens/ceremony"
+	"goauth/tokens/challenge"
+	"goauth/tokens/magiclink"
+	"goauth/tokens/verification"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Сведения о клиенте OAuth 2.0 для администратора.
+type RegisteredClient struct {
+	// Идентификатор клиента.
+	Id string
+
+	// Название клиента.
+	Name string
+
+	// Признак конфиденциального клиента, который аутентифицируется секретом.
+	Confidential bool
+
+	// Гранты, которые разрешено использовать клиенту.
+	Grants []string
+
+	// Области доступа, которые разрешено запрашивать клиенту.
+	Scopes []string
+
+	// Адреса перенаправления для гранта authorization_code.
+	RedirectUris []string
+
+	// Момент времени регистрации клиента.
+	CreatedAt time.Time
+
+	// Секрет клиента. Заполняется только при регистрации и замене секрета и больше нигде не показывается.
+	Secret string `json:",omitempty"`
+}
+
+// Допустимый идентификатор клиента.
+var clientIdPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Идентификатор клиента, состоящий только из цифр. Не допускается: идентификатор клиента указывается в утверждении `sub`
+// токенов гранта client_credentials и не должен совпадать с идентификатором пользователя.
+var numericClientIdPattern = regexp.MustCompile(`^[0-9]+$`)
+
+// Идентификаторы, которые не могут быть выданы клиентам: они совпадают с получателями токенов, подписанных ключом ACCESS токенов,
+// и ID токен такого клиента был бы принят вместо токена другого вида.
+var reservedClientIds = []string{
+	secrets.ACCESS_TOKEN_AUDIENCE,
+	verification.AUDIENCE,
+	challenge.AUDIENCE,
+	magiclink.AUDIENCE,
+	ceremony.REGISTRATION_AUDIENCE,
+	ceremony.AUTHENTICATION_AUDIENCE,
+}
+
+// Допустимая область доступа (RFC 6749, раздел 3.3).
+var scopeTokenPattern = regexp.MustCompile(`^[\x21\x23-\x5B\x5D-\x7E]+$`)
+
+// Гранты, которые поддерживаются конечной точкой токенов.
+var supportedGrants = []string{AUTHORIZATION_CODE_GRANT, REFRESH_TOKEN_GRANT, CLIENT_CREDENTIALS_GRANT}
+
+// Запрос на получение зарегистрированных клиентов OAuth 2.0.
+type ClientsQuery struct {
+	// ACCESS токен администратора.
+	AccessToken string
+}
+
+// Обработчик запроса на получение зарегистрированных клиентов OAuth 2.0.
+type ClientsQueryHandler struct {
+	// Обрабатываемый запрос.
+	Query *ClientsQuery
+}
+
+// Обработать запрос на получение зарегистрированных клиентов OAuth 2.0.
+func (s *ClientsQueryHandler) Handle() []RegisteredClient {
+	panicIfUserIsNotAdmin(s.Query.AccessToken)
+
+	registered, err := services.ClientsRepository().List()
+	if err != nil {
+		panic(err)
+	}
+
+	result := []RegisteredClient{}
+	for _, client := range registered {
+		result = append(result, registeredClient(&client))
+	}
+
+	return result
+}
+
+// Команда на регистрацию клиента OAuth 2.0.
+type ClientCreationCommand struct {
+	// ACCESS токен администратора.
+	AccessToken string
+
+	// Идентификатор клиента. Если не указан, генерируется.
+	Id string
+
+	// Название клиента.
+	Name string
+
+	// Признак конфиденциального клиента. Для него генерируется секрет.
+	Confidential bool
+
+	// Гранты, которые разрешено использовать клиенту.
+	Grants []string
+
+	// Области доступа, которые разрешено запрашивать клиенту.
+	Scopes []string
+
+	// Адреса перенаправления для гранта authorization_code.
+	RedirectUris []string
+}
+
+// Обработчик команды на регистрацию клиента OAuth 2.0.
+type ClientCreationCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *ClientCreationCommand
+}
+
+// Обработать команду на регистрацию клиента OAuth 2.0.
+func (s *ClientCreationCommandHandler) Handle() *RegisteredClient {
+	panicIfUserIsNotAdmin(s.Command.AccessToken)
+
+	client := clients.Client{
+		Id:           s.clientId(),
+		Name:         s.Command.Name,
+		Grants:       emptyIfNil(s.Command.Grants),
+		Scopes:       emptyIfNil(s.Command.Scopes),
+		RedirectUris: emptyIfNil(s.Command.RedirectUris),
+	}
+
+	secret := ""
+	if s.Command.Confidential {
+		secret = *generateRandomString()
+		client.SecretHash = services.ClientSecretDigester().Digest(secret)
+	}
+
+	validateClient(&client)
+
+	createdClient, err := services.ClientsRepository().Create(client)
+	if errors.Is(err, clients.ErrClientIdTaken) {
+		panic(fmt.Errorf("%w: %w", ErrInvalidRequest, err))
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	result := registeredClient(createdClient)
+	result.Secret = secret
+
+	return &result
+}
+
+// 1-й уровень абстракции.
+
+func (s *ClientCreationCommandHandler) clientId() string {
+	if s.Command.Id != "" {
+		return s.Command.Id
+	}
+
+	value := make([]byte, 16)
+	_, err := rand.Read(value)
+	if err != nil {
+		panic(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(value)
+}
+
+// Команда на изменение клиента OAuth 2.0.
+type ClientUpdateCommand struct {
+	// ACCESS токен администратора.
+	AccessToken string
+
+	// Идентификатор клиента.
+	Id string
+
+	// Название клиента.
+	Name string
+
+	// Гранты, которые разрешено использовать клиенту.
+	Grants []string
+
+	// Области доступа, которые разрешено запрашивать клиенту.
+	Scopes []string
+
+	// Адреса перенаправления для гранта authorization_code.
+	RedirectUris []string
+}
+
+// Обработчик команды на изменение клиента OAuth 2.0. Конфиденциальность клиента и его секрет не изменяются.
+type ClientUpdateCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *ClientUpdateCommand
+}
+
+// Обработать команду на изменение клиента OAuth 2.0.
+func (s *ClientUpdateCommandHandler) Handle() {
+	panicIfUserIsNotAdmin(s.Command.AccessToken)
+
+	client := getClientOrPanic(s.Command.Id)
+	client.Name = s.Command.Name
+	client.Grants = emptyIfNil(s.Command.Grants)
+	client.Scopes = emptyIfNil(s.Command.Scopes)
+	client.RedirectUris = emptyIfNil(s.Command.RedirectUris)
+
+	validateClient(client)
+
+	updated, err := services.ClientsRepository().Update(*client)
+	if err != nil {
+		panic(err)
+	}
+
+	if !updated {
+		panicBecauseOfUnknownClient(s.Command.Id)
+	}
+}
+
+// Команда на замену секрета конфиденциального клиента OAuth 2.0.
+type ClientSecretRotationCommand struct {
+	// ACCESS токен администратора.
+	AccessToken string
+
+	// Идентификатор клиента.
+	Id string
+}
+
+// Обработчик команды на замену секрета конфиденциального клиента OAuth 2.0.
+//
+// Прежний секрет сразу перестает приниматься, а ACCESS токены, выданные клиенту по гранту client_credentials, отзываются.
+type ClientSecretRotationCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *ClientSecretRotationCommand
+}
+
+// Обработать команду на замену секрета конфиденциального клиента OAuth 2.0.
+func (s *ClientSecretRotationCommandHandler) Handle() *RegisteredClient {
+	panicIfUserIsNotAdmin(s.Command.AccessToken)
+
+	client := getClientOrPanic(s.Command.Id)
+	if client.SecretHash == "" {
+		panic(fmt.Errorf("%w: the client %s is public and has no secret", ErrInvalidRequest, client.Id))
+	}
+
+	secret := *generateRandomString()
+
+	updated, err := services.ClientsRepository().UpdateSecretHash(client.Id, services.ClientSecretDigester().Digest(secret))
+	if err != nil {
+		panic(err)
+	}
+
+	if !updated {
+		panicBecauseOfUnknownClient(s.Command.Id)
+	}
+
+	revokeDeletedClientTokens(services.ClientTokensRepository().DeleteByClient(client.Id))
+
+	result := registeredClient(client)
+	result.Secret = secret
+
+	return &result
+}
+
+// Команда на удаление клиента OAuth 2.0.
+type ClientDeletionCommand struct {
+	// ACCESS токен администратора.
+	AccessToken string
+
+	// Идентификатор клиента.
+	Id string
+}
+
+// Обработчик команды на удаление клиента OAuth 2.0.
+//
+// Выданные клиенту по гранту client_credentials ACCESS токены отзываются. Сеансы, начатые через клиента по коду авторизации,
+// завершаются: записи таблицы AUTHS удаляются, а их ACCESS токены отзываются, чтобы клиент не мог пользоваться ими после удаления.
+type ClientDeletionCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *ClientDeletionCommand
+}
+
+// Обработать команду на удаление клиента OAuth 2.0.
+func (s *ClientDeletionCommandHandler) Handle() {
+	panicIfUserIsNotAdmin(s.Command.AccessToken)
+
+	revokeDeletedClientTokens(services.ClientTokensRepository().DeleteByClient(s.Command.Id))
+	revokeDeletedAuths(services.AuthsRepository().DeleteByClient(s.Command.Id))
+
+	deleted, err := services.ClientsRepository().Delete(s.Command.Id)
+	if err != nil {
+		panic(err)
+	}
+
+	if !deleted {
+		panicBecauseOfUnknownClient(s.Command.Id)
+	}
+}
+
+// Убедиться, что ACCESS токен выдан пользователю с ролью `services.ADMIN_ROLE`. Роли читаются из БД, а не из токена.
+func panicIfUserIsNotAdmin(accessToken string) {
+	if !slices.Contains(getVerifiedUser(accessToken).Roles, services.ADMIN_ROLE) {
+		panic(fmt.Errorf("%w: the user is not an administrator", ErrForbidden))
+	}
+}
+
+// Проверить гранты, области доступа и адреса перенаправления клиента.
+func validateClient(client *clients.Client) {
+	if !clientIdPattern.MatchString(client.Id) {
+		panic(fmt.Errorf("%w: the client id must consist of 1 to 64 letters, digits, dots, dashes or underscores", ErrInvalidRequest))
+	}
+
+	if numericClientIdPattern.MatchString(client.Id) {
+		panic(fmt.Errorf("%w: the client id must not consist of digits only", ErrInvalidRequest))
+	}
+
+	if slices.Contains(reservedClientIds, client.Id) {
+		panic(fmt.Errorf("%w: the client id %s is reserved", ErrInvalidRequest, client.Id))
+	}
+
+	for _, grant := range client.Grants {
+		if !slices.Contains(supportedGrants, grant) {
+			panic(fmt.Errorf("%w: the grant %s is not supported", ErrInvalidRequest, grant))
+		}
+	}
+
+	if slices.Contains(client.Grants, CLIENT_CREDENTIALS_GRANT) && client.SecretHash == "" {
+		panic(fmt.Errorf("%w: only confidential clients can use the %s grant", ErrInvalidRequest, CLIENT_CREDENTIALS_GRANT))
+	}
+
+	if slices.Contains(client.Grants, REFRESH_TOKEN_GRANT) && !slices.Contains(client.Grants, AUTHORIZATION_CODE_GRANT) {
+		panic(fmt.Errorf("%w: the %s grant requires the %s grant", ErrInvalidRequest, REFRESH_TOKEN_GRANT, AUTHORIZATION_CODE_GRANT))
+	}
+
+	if slices.Contains(client.Grants, AUTHORIZATION_CODE_GRANT) && len(client.RedirectUris) == 0 {
+		panic(fmt.Errorf("%w: the %s grant requires at least one redirect URI", ErrInvalidRequest, AUTHORIZATION_CODE_GRANT))
+	}
+
+	for _, scope := range client.Scopes {
+		if !scopeTokenPattern.MatchString(scope) {
+			panic(fmt.Errorf("%w: the scope %q is invalid", ErrInvalidRequest, scope))
+		}
+	}
+
+	for _, redirectUri := range client.RedirectUris {
+		parsed, err := url.Parse(redirectUri)
+		if err != nil || !parsed.IsAbs() || parsed.Fragment != "" {
+			panic(fmt.Errorf("%w: the redirect URI %q must be an absolute URI without a fragment", ErrInvalidRequest, redirectUri))
+		}
+	}
+}
+
+// Получить зарегистрированного клиента. nil, если клиент не найден.
+func getClient(clientId string) *clients.Client {
+	client, err := services.ClientsRepository().Get(clientId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	return client
+}
+
+// Получить зарегистрированного клиента для управления им.
+func getClientOrPanic(clientId string) *clients.Client {
+	client := getClient(clientId)
+	if client == nil {
+		panicBecauseOfUnknownClient(clientId)
+	}
+
+	return client
+}
+
+// Аутентифицировать клиента по идентификатору и секрету. Публичные клиенты аутентифицируются только идентификатором.
+func authenticateClient(clientId string, clientSecret string) *clients.Client {
+	client := getClient(clientId)
+	if client == nil {
+		panicBecauseOfInvalidClient()
+	}
+
+	if client.SecretHash == "" {
+		if clientSecret != "" {
+			panicBecauseOfInvalidClient()
+		}
+
+		return client
+	}
+
+	err := services.ClientSecretDigester().Verify(clientSecret, client.SecretHash)
+	if err != nil {
+		panicBecauseOfInvalidClient()
+	}
+
+	return client
+}
+
+// Получить области доступа, разрешенные клиенту, из запрошенных. Если области не запрошены, возвращает пустую строку.
+//
+// Возвращает false, если запрошена область, которая клиенту не разрешена.
+func allowedScope(client *clients.Client, requestedScope string) (string, bool) {
+	requested := strings.Fields(requestedScope)
+	for _, scope := range requested {
+		if !slices.Contains(client.Scopes, scope) {
+			return "", false
+		}
+	}
+
+	return strings.Join(requested, " "), true
+}
+
+func registeredClient(client *clients.Client) RegisteredClient {
+	return RegisteredClient{
+		Id:           client.Id,
+		Name:         client.Name,
+		Confidential: client.SecretHash != "",
+		Grants:       client.Grants,
+		Scopes:       client.Scopes,
+		RedirectUris: client.RedirectUris,
+		CreatedAt:    client.CreatedAt,
+	}
+}
+
+func emptyIfNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+
+	return values
+}
+
+func panicBecauseOfInvalidClient() {
+	panic(&OAuthError{Code: "invalid_client", Description: "the client is unknown or its credentials are invalid", Err: ErrUnauthorized})
+}
+
+func panicBecauseOfUnknownClient(clientId string) {
+	panic(fmt.Errorf("%w: the client %s is not found", ErrNotFound, clientId))
+}
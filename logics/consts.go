@@ -1,3 +1,30 @@
 package logics
 
 const MAX_BYTES_IN_VALUE_FOR_BCRYPT = 72
+
+// Минимальная длина пароля в символах.
+const MIN_PASSWORD_LENGTH = 8
+
+// Максимальная длина пароля в байтах. Ограничивает время вычисления хэша.
+const MAX_PASSWORD_LENGTH_IN_BYTES = 256
+
+// Максимальная длина адреса электронной почты в байтах (RFC 5321, раздел 4.5.3.1, с учетом угловых скобок пути).
+const MAX_EMAIL_LENGTH_IN_BYTES = 254
+
+// Количество кодов восстановления, выдаваемых при подключении второго фактора.
+const RECOVERY_CODES_COUNT = 10
+
+// Грант OAuth 2.0 по коду авторизации (RFC 6749, раздел 4.1).
+const AUTHORIZATION_CODE_GRANT = "authorization_code"
+
+// Грант OAuth 2.0 по учетным данным клиента (RFC 6749, раздел 4.4).
+const CLIENT_CREDENTIALS_GRANT = "client_credentials"
+
+// Грант OAuth 2.0 по REFRESH токену (RFC 6749, раздел 6).
+const REFRESH_TOKEN_GRANT = "refresh_token"
+
+// Область доступа, по которой выдается ID токен OpenID Connect.
+const OPENID_SCOPE = "openid"
+
+// Область доступа к адресу электронной почты пользователя в ID токене и ответе `/userinfo`.
+const EMAIL_SCOPE = "email"
@@ -0,0 +1,57 @@
+package logics
+
+import (
+	"fmt"
+	"goauth/logics/services"
+	"goauth/tokens/jwt"
+	"goauth/tokens/verification"
+)
+
+// Команда на подтверждение адреса электронной почты пользователя.
+type EmailVerificationCommand struct {
+	// Токен подтверждения из письма.
+	Token string
+}
+
+// Обработчик команды на подтверждение адреса электронной почты пользователя.
+//
+// Токен одноразовый: после подтверждения адреса повторное предъявление токена завершается ошибкой.
+type EmailVerificationCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *EmailVerificationCommand
+
+	_token *jwt.Jwt[verification.EmailVerificationTokenPayload]
+}
+
+// Обработать команду на подтверждение адреса электронной почты пользователя.
+func (s *EmailVerificationCommandHandler) Handle() {
+	verified, err := services.UsersRepository().MarkEmailVerified(s.token().Payload.Subject, s.token().Payload.Email)
+	if err != nil {
+		panic(err)
+	}
+
+	if !verified {
+		panic(fmt.Errorf("%w: the token has already been used or the email has been changed", ErrInvalidRequest))
+	}
+}
+
+// 1-й уровень абстракции.
+
+func (s *EmailVerificationCommandHandler) token() *jwt.Jwt[verification.EmailVerificationTokenPayload] {
+	if s._token == nil {
+		s._token = s.decodeToken()
+	}
+
+	return s._token
+}
+
+// 2-й уровень абстракции.
+
+func (s *EmailVerificationCommandHandler) decodeToken() *jwt.Jwt[verification.EmailVerificationTokenPayload] {
+	token, err := services.EmailVerificationTokenIssuer().Decode(s.Command.Token)
+	if err != nil {
+		panic(fmt.Errorf("%w: %w", ErrInvalidRequest, err))
+	}
+
+	return token
+}
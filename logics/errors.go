@@ -0,0 +1,60 @@
+package logics
+
+import "errors"
+
+// Пользователь не аутентифицирован или предъявленный токен недействителен.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// Пользователь аутентифицирован, но действие ему не разрешено.
+var ErrForbidden = errors.New("forbidden")
+
+// Запрос содержит недопустимые данные.
+var ErrInvalidRequest = errors.New("invalid request")
+
+// Запрошенный объект не найден.
+var ErrNotFound = errors.New("not found")
+
+// Ошибка протокола OAuth 2.0 (RFC 6749) с кодом, который возвращается клиенту.
+type OAuthError struct {
+	// Код ошибки, например, "invalid_grant".
+	Code string
+
+	// Описание ошибки для разработчика клиента.
+	Description string
+
+	// Ошибка, определяющая код ответа HTTP: ErrInvalidRequest или ErrUnauthorized.
+	Err error
+}
+
+// Получить текст ошибки.
+func (s *OAuthError) Error() string {
+	return s.Code + ": " + s.Description
+}
+
+// Получить ошибку, определяющую код ответа HTTP.
+func (s *OAuthError) Unwrap() error {
+	return s.Err
+}
+
+// Выполнить действие и вернуть ошибку, если оно завершилось паникой с ошибкой ErrUnauthorized.
+//
+// Остальные паники не перехватываются.
+func catchUnauthorized(action func()) (err error) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+
+		recoveredErr, ok := recovered.(error)
+		if !ok || !errors.Is(recoveredErr, ErrUnauthorized) {
+			panic(recovered)
+		}
+
+		err = recoveredErr
+	}()
+
+	action()
+
+	return nil
+}
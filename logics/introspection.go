@@ -0,0 +1,180 @@
+package logics
+
+import (
+	"goauth/logics/services"
+	"goauth/tokens/access"
+	"goauth/tokens/jwt"
+	"slices"
+	"strconv"
+)
+
+// Запрос на получение сведений о токене (RFC 7662).
+type IntrospectionQuery struct {
+	// ACCESS или REFRESH токен.
+	Token string
+
+	// Предполагаемый тип токена: "access_token", "refresh_token" или пустая строка.
+	TokenTypeHint string
+
+	// Идентификатор клиента, запрашивающего сведения.
+	ClientId string
+
+	// Секрет клиента, запрашивающего сведения.
+	ClientSecret string
+}
+
+// Сведения о токене (RFC 7662).
+type IntrospectionResult struct {
+	// Признак действительного токена. Остальные поля заполняются только для действительного токена.
+	Active bool `json:"active"`
+
+	// Области доступа токена, разделенные пробелами.
+	Scope string `json:"scope,omitempty"`
+
+	// Идентификатор клиента, которому был выдан токен.
+	ClientId string `json:"client_id,omitempty"`
+
+	// Тип токена.
+	TokenType string `json:"token_type,omitempty"`
+
+	// Момент времени, до которого токен считается действительным в формате UNIX.
+	ExpirationTime int64 `json:"exp,omitempty"`
+
+	// Момент времени, когда токен был выдан в формате UNIX.
+	IssuedAt int64 `json:"iat,omitempty"`
+
+	// Идентификатор пользователя, которому был выдан токен.
+	Subject string `json:"sub,omitempty"`
+
+	// Получатели, для которых предназначен токен.
+	Audience jwt.Audience `json:"aud,omitempty"`
+
+	// Имя издателя токена.
+	Issuer string `json:"iss,omitempty"`
+
+	// Идентификатор токена.
+	Id string `json:"jti,omitempty"`
+}
+
+// Обработчик запроса на получение сведений о токене.
+//
+// Сведения выдаются только конфиденциальным клиентам, зарегистрированным в таблице CLIENTS. Токен проверяется так же, как при его использовании: подпись, утверждения, отзыв и наличие записи в таблице AUTHS.
+type IntrospectionQueryHandler struct {
+	// Обрабатываемый запрос.
+	Query *IntrospectionQuery
+}
+
+// Обработать запрос на получение сведений о токене.
+func (s *IntrospectionQueryHandler) Handle() *IntrospectionResult {
+	s.panicIfClientIsNotAuthenticated()
+
+	for _, introspect := range s.introspectors() {
+		result := introspect()
+		if result.Active {
+			return result
+		}
+	}
+
+	return &IntrospectionResult{Active: false}
+}
+
+// 1-й уровень абстракции.
+
+func (s *IntrospectionQueryHandler) panicIfClientIsNotAuthenticated() {
+	client := authenticateClient(s.Query.ClientId, s.Query.ClientSecret)
+	if client.SecretHash == "" {
+		panicBecauseOfInvalidClient()
+	}
+}
+
+// Получить функции проверки токена в порядке, соответствующем предполагаемому типу токена.
+func (s *IntrospectionQueryHandler) introspectors() []func() *IntrospectionResult {
+	if s.Query.TokenTypeHint == "refresh_token" {
+		return []func() *IntrospectionResult{s.introspectRefreshToken, s.introspectAccessToken, s.introspectClientAccessToken}
+	}
+
+	return []func() *IntrospectionResult{s.introspectAccessToken, s.introspectClientAccessToken, s.introspectRefreshToken}
+}
+
+// 2-й уровень абстракции.
+
+func (s *IntrospectionQueryHandler) introspectAccessToken() *IntrospectionResult {
+	var verification *AccessTokenVerificationResult
+
+	err := catchUnauthorized(func() {
+		verification = verifyAccessTokenOfAnyClient(s.Query.Token)
+	})
+	if err != nil {
+		return &IntrospectionResult{Active: false}
+	}
+
+	payload := verification.Token.Payload
+
+	return &IntrospectionResult{
+		Active:         true,
+		Scope:          payload.Scope,
+		TokenType:      "Bearer",
+		ExpirationTime: payload.ExpirationTime,
+		IssuedAt:       payload.IssuedAt,
+		ClientId:       payload.ClientId,
+		Subject:        payload.Subject,
+		Audience:       payload.Audience,
+		Issuer:         payload.Issuer,
+		Id:             strconv.Itoa(int(payload.Id)),
+	}
+}
+
+// Проверить ACCESS токен, выданный клиенту по гранту client_credentials: такой токен действителен, пока не отозван
+// и клиенту разрешен этот грант.
+func (s *IntrospectionQueryHandler) introspectClientAccessToken() *IntrospectionResult {
+	var token *jwt.Jwt[access.AccessTokenPayload]
+
+	err := catchUnauthorized(func() {
+		token = verifyClientAccessToken(s.Query.Token)
+	})
+	if err != nil {
+		return &IntrospectionResult{Active: false}
+	}
+
+	payload := token.Payload
+
+	client := getClient(payload.ClientId)
+	if client == nil || !slices.Contains(client.Grants, CLIENT_CREDENTIALS_GRANT) {
+		return &IntrospectionResult{Active: false}
+	}
+
+	return &IntrospectionResult{
+		Active:         true,
+		Scope:          payload.Scope,
+		ClientId:       payload.ClientId,
+		TokenType:      "Bearer",
+		ExpirationTime: payload.ExpirationTime,
+		IssuedAt:       payload.IssuedAt,
+		Subject:        payload.Subject,
+		Audience:       payload.Audience,
+		Issuer:         payload.Issuer,
+		Id:             strconv.Itoa(int(payload.Id)),
+	}
+}
+
+func (s *IntrospectionQueryHandler) introspectRefreshToken() *IntrospectionResult {
+	var verification *RefreshTokenVerificationResult
+
+	err := catchUnauthorized(func() {
+		verification = verifyRefreshToken(s.Query.Token)
+	})
+	if err != nil || refreshTokenIsReused(verification.Auth) {
+		return &IntrospectionResult{Active: false}
+	}
+
+	return &IntrospectionResult{
+		Active:         true,
+		Scope:          verification.Auth.Scope,
+		ExpirationTime: verification.Auth.ExpiresAt.Unix(),
+		IssuedAt:       verification.Auth.LastUsedAt.Unix(),
+		ClientId:       verification.Auth.ClientId,
+		Subject:        strconv.Itoa(int(verification.Auth.UserId)),
+		Issuer:         services.RefreshTokenIssuer().Name,
+		Id:             strconv.Itoa(int(verification.Auth.Id)),
+	}
+}
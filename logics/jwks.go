@@ -0,0 +1,23 @@
+package logics
+
+import (
+	"goauth/logics/services"
+	"goauth/tokens/jwt"
+)
+
+// Обработчик запроса на получение ключей для проверки подписи ACCESS и ID токенов.
+type JwksQueryHandler struct {
+}
+
+// Обработать запрос на получение ключей для проверки подписи ACCESS и ID токенов.
+func (s *JwksQueryHandler) Handle() *jwt.JwkSet {
+	result := jwt.NewJwkSet(s.verificationKeys()...)
+
+	return &result
+}
+
+// 1-й уровень абстракции.
+
+func (s *JwksQueryHandler) verificationKeys() []jwt.Key {
+	return append(services.AccessTokenIssuer().Keys.VerificationKeys(), services.IdTokenIssuer().Keys.VerificationKeys()...)
+}
@@ -1,25 +1,48 @@
 package logics
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"goauth/data/mfachallenges"
+	"goauth/data/users"
 	"goauth/logics/services"
+	"goauth/passwords"
+	"goauth/tokens/challenge"
+	"sync"
+	"time"
 )
 
 // Команда для аутентификации пользователя.
 type LoginCommand struct {
-	// Идентификатор пользователя, которому требуется выдать пару токенов.
-	UserId int32
+	// Адрес электронной почты пользователя.
+	Login string
+
+	// Пароль пользователя.
+	Password string
 
 	// IP адрес пользователя.
 	UserIp string
+
+	// Название устройства пользователя.
+	DeviceName string
+
+	// Значение заголовка User-Agent запроса пользователя.
+	UserAgent string
 }
 
 // Результат аутентификации пользователя.
+//
+// Если у пользователя включен второй фактор, вместо пары токенов содержит токен второго шага аутентификации.
 type LoginResult struct {
 	// ACCESS токен.
-	AccessToken string
+	AccessToken string `json:",omitempty"`
 
 	// REFRESH токен.
-	RefreshToken string
+	RefreshToken string `json:",omitempty"`
+
+	// Токен второго шага аутентификации, который обменивается на пару токенов вместе с кодом второго фактора.
+	MfaChallengeToken string `json:",omitempty"`
 }
 
 // Обработчик команды для аутентификации пользователя.
@@ -27,77 +50,145 @@ type LoginCommandHandler struct {
 	// Обрабатываемая команда.
 	Command *LoginCommand
 
-	_tokensCreationHandler *TokensCreationCommandHandler
-	_createdPairOfTokens   *TokensCreationResult
+	_user *users.User
 }
 
+// Способы аутентификации (RFC 8176), используемые при аутентификации по паролю.
+var passwordAmr = []string{"pwd"}
+
+// Хэш, с которым сверяется пароль, если пользователь не найден, чтобы время ответа не выдавало его отсутствие.
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, err := services.PasswordParameters().Hash("")
+	if err != nil {
+		panic(err)
+	}
+
+	return hash
+})
+
 // Обработать команду для аутентификации пользователя.
 func (s *LoginCommandHandler) Handle() *LoginResult {
-	s.panicIfUserDoesNotExist()
+	s.validatePassword()
+	s.panicIfEmailIsNotVerified()
+
+	s.rehashPasswordIfNeeded()
 
-	s.deletePreviousAuth()
+	if s.user().TotpEnabled {
+		return s.mfaChallengeResult()
+	}
 
 	return s.result()
 }
 
 // 1-й уровень абстракции.
 
-func (s *LoginCommandHandler) panicIfUserDoesNotExist() {
-	_, err := services.UsersRepository().Get(s.Command.UserId)
+func (s *LoginCommandHandler) validatePassword() {
+	user := s.user()
+	if user == nil || user.PasswordHash == "" {
+		passwords.Verify(s.Command.Password, dummyPasswordHash())
+		panicBecauseOfInvalidCredentials()
+	}
+
+	err := passwords.Verify(s.Command.Password, user.PasswordHash)
 	if err != nil {
-		panic(err)
+		panicBecauseOfInvalidCredentials()
+	}
+}
+
+func (s *LoginCommandHandler) panicIfEmailIsNotVerified() {
+	if services.UNVERIFIED_USERS_POLICY == services.DenyUnverifiedUsers && !s.user().EmailVerified {
+		panic(fmt.Errorf("%w: the email is not verified", ErrForbidden))
 	}
 }
 
-func (s *LoginCommandHandler) deletePreviousAuth() {
-	err := services.AuthsRepository().DeleteByUser(s.Command.UserId)
+func (s *LoginCommandHandler) rehashPasswordIfNeeded() {
+	if !services.PasswordParameters().NeedsRehash(s.user().PasswordHash) {
+		return
+	}
+
+	hash, err := services.PasswordParameters().Hash(s.Command.Password)
+	if err != nil {
+		panic(err)
+	}
+
+	err = services.UsersRepository().UpdatePasswordHash(s.user().Id, hash)
 	if err != nil {
 		panic(err)
 	}
 }
 
+func (s *LoginCommandHandler) mfaChallengeResult() *LoginResult {
+	return createMfaChallenge(s.user().Id, passwordAmr, s.Command.DeviceName)
+}
+
 func (s *LoginCommandHandler) result() *LoginResult {
-	return &LoginResult{
-		AccessToken:  s.createdPairOfTokens().AccessToken,
-		RefreshToken: s.createdPairOfTokens().RefreshToken,
-	}
+	return createSession(&SessionCreationCommand{
+		UserId:     s.user().Id,
+		UserIp:     s.Command.UserIp,
+		DeviceName: s.Command.DeviceName,
+		UserAgent:  s.Command.UserAgent,
+		Amr:        passwordAmr,
+	})
 }
 
 // 2-й уровень абстракции.
 
-func (s *LoginCommandHandler) createdPairOfTokens() *TokensCreationResult {
-	if s._createdPairOfTokens == nil {
-		s._createdPairOfTokens = s.createPairOfTokens()
+func (s *LoginCommandHandler) user() *users.User {
+	if s._user == nil {
+		s._user = s.getUser()
 	}
 
-	return s._createdPairOfTokens
+	return s._user
 }
 
 // 3-й уровень абстракции.
 
-func (s *LoginCommandHandler) createPairOfTokens() *TokensCreationResult {
-	return s.tokenCreationHandler().Handle()
+func (s *LoginCommandHandler) getUser() *users.User {
+	user, err := services.UsersRepository().GetByEmail(s.Command.Login)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	return user
 }
 
-// 4-й уровень абстракции.
+// Выдать токен второго шага аутентификации пользователю, прошедшему первый шаг указанными способами.
+//
+// Второй шаг сохраняется в таблице MFA_CHALLENGES, чтобы токен можно было использовать однократно. Записи пользователя,
+// созданные до периода учета попыток, удаляются.
+func createMfaChallenge(userId int32, amr []string, deviceName string) *LoginResult {
+	now := time.Now()
 
-func (s *LoginCommandHandler) tokenCreationHandler() *TokensCreationCommandHandler {
-	if s._tokensCreationHandler == nil {
-		s._tokensCreationHandler = s.createTokenCreationHandler()
+	err := services.MfaChallengesRepository().DeleteCreatedBefore(userId, now.Add(-services.MFA_ATTEMPTS_WINDOW))
+	if err != nil {
+		panic(err)
 	}
 
-	return s._tokensCreationHandler
-}
+	mfaChallenge, err := services.MfaChallengesRepository().Create(mfachallenges.MfaChallenge{
+		UserId:    userId,
+		ExpiresAt: now.Add(services.MFA_CHALLENGE_LIFETIME),
+	})
+	if err != nil {
+		panic(err)
+	}
 
-// 5-й уровень абстракции.
+	issuer := services.MfaChallengeTokenIssuer()
 
-func (s *LoginCommandHandler) createTokenCreationHandler() *TokensCreationCommandHandler {
-	tokenCreationHandler := TokensCreationCommandHandler{
-		Command: &TokensCreationCommand{
-			UserId: s.Command.UserId,
-			UserIp: s.Command.UserIp,
-		},
+	token, err := issuer.Encode(challenge.New(issuer, userId, mfaChallenge.Id, amr, deviceName))
+	if err != nil {
+		panic(err)
 	}
 
-	return &tokenCreationHandler
+	return &LoginResult{
+		MfaChallengeToken: token,
+	}
+}
+
+// Завершить аутентификацию ошибкой, не раскрывающей, существует ли пользователь и какой из параметров неверен.
+func panicBecauseOfInvalidCredentials() {
+	panic(fmt.Errorf("%w: invalid login or password", ErrUnauthorized))
 }
@@ -0,0 +1,63 @@
+package logics
+
+import (
+	"goauth/logics/services"
+)
+
+// Команда на завершение сеанса пользователя.
+type LogoutCommand struct {
+	// ACCESS токен пользователя.
+	AccessToken string
+
+	// REFRESH токен пользователя.
+	RefreshToken string
+
+	// IP адрес пользователя.
+	UserIp string
+
+	// Признак завершения всех сеансов пользователя, а не только текущего.
+	Everywhere bool
+}
+
+// Обработчик команды на завершение сеанса пользователя.
+type LogoutCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *LogoutCommand
+
+	_verification *TokenPairVerificationResult
+}
+
+// Обработать команду на завершение сеанса пользователя.
+func (s *LogoutCommandHandler) Handle() {
+	s.validateCommand()
+
+	if s.Command.Everywhere {
+		s.deleteAllAuths()
+	} else {
+		s.deleteCurrentAuthFamily()
+	}
+}
+
+// 1-й уровень абстракции.
+
+func (s *LogoutCommandHandler) validateCommand() {
+	s.verification()
+}
+
+func (s *LogoutCommandHandler) deleteAllAuths() {
+	revokeDeletedAuths(services.AuthsRepository().DeleteByUser(s.verification().Auth.UserId))
+}
+
+func (s *LogoutCommandHandler) deleteCurrentAuthFamily() {
+	revokeDeletedAuths(services.AuthsRepository().DeleteByFamily(s.verification().Auth.FamilyId))
+}
+
+// 2-й уровень абстракции.
+
+func (s *LogoutCommandHandler) verification() *TokenPairVerificationResult {
+	if s._verification == nil {
+		s._verification = verifyTokenPair(s.Command.AccessToken, s.Command.RefreshToken, s.Command.UserIp)
+	}
+
+	return s._verification
+}
@@ -0,0 +1,309 @@
+package logics
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"goauth/data/magiclinks"
+	"goauth/data/users"
+	"goauth/logics/services"
+	"goauth/secrets"
+	"goauth/tokens/jwt"
+	"goauth/tokens/magiclink"
+	"net/url"
+	"time"
+)
+
+// Команда на отправку пользователю ссылки для входа без пароля.
+type MagicLinkCommand struct {
+	// Адрес электронной почты пользователя.
+	Email string
+}
+
+// Результат отправки ссылки для входа.
+type MagicLinkResult struct {
+	// Одноразовое значение, которое сохраняется в браузере, запросившем ссылку, и предъявляется при переходе по ней.
+	Nonce string
+}
+
+// Обработчик команды на отправку пользователю ссылки для входа без пароля.
+//
+// Если пользователь не найден, письмо не отправляется, но результат не отличается. Иначе ссылка создается и письмо отправляется в фоне,
+// чтобы ни ответ, ни время ответа не выдавали, зарегистрирован ли адрес.
+type MagicLinkCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *MagicLinkCommand
+
+	_user  *users.User
+	_nonce *string
+}
+
+// Обработать команду на отправку пользователю ссылки для входа без пароля.
+func (s *MagicLinkCommandHandler) Handle() *MagicLinkResult {
+	result := &MagicLinkResult{
+		Nonce: *s.nonce(),
+	}
+
+	if s.user() != nil {
+		runInBackground("Не удалось отправить ссылку для входа", s.sendMagicLink)
+	}
+
+	return result
+}
+
+// 1-й уровень абстракции.
+
+func (s *MagicLinkCommandHandler) user() *users.User {
+	if s._user == nil {
+		s._user = s.getUser()
+	}
+
+	return s._user
+}
+
+func (s *MagicLinkCommandHandler) sendMagicLink() {
+	s.deletePreviousMagicLinks()
+
+	s.createNotificationCommandHandler(s.createToken(s.createMagicLink())).Handle()
+}
+
+// 2-й уровень абстракции.
+
+func (s *MagicLinkCommandHandler) deletePreviousMagicLinks() {
+	err := services.MagicLinksRepository().DeleteByUser(s.user().Id)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (s *MagicLinkCommandHandler) createMagicLink() *magiclinks.MagicLink {
+	magicLink, err := services.MagicLinksRepository().Create(magiclinks.MagicLink{
+		UserId:    s.user().Id,
+		NonceHash: services.OneTimeTokenDigester().Digest(*s.nonce()),
+		ExpiresAt: time.Now().Add(services.MAGIC_LINK_TOKEN_LIFETIME),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return magicLink
+}
+
+func (s *MagicLinkCommandHandler) createToken(magicLink *magiclinks.MagicLink) string {
+	issuer := services.MagicLinkTokenIssuer()
+
+	token, err := issuer.Encode(magiclink.New(issuer, s.user().Id, magicLink.Id))
+	if err != nil {
+		panic(err)
+	}
+
+	return token
+}
+
+func (s *MagicLinkCommandHandler) createNotificationCommandHandler(token string) *NotificationCommandHandler {
+	return &NotificationCommandHandler{
+		Command: &NotificationCommand{
+			ReceiverEmail:  s.user().Email,
+			MessageSubject: "(shumilija/goauth) Вход по ссылке",
+			MessageBody: "Для входа перейдите по ссылке: " + secrets.APPLICATION_URL + "/magic-link?token=" + url.QueryEscape(token) +
+				". Ссылка действительна " + services.MAGIC_LINK_TOKEN_LIFETIME.String() + " и только в браузере, из которого был запрошен вход. " +
+				"Если вы не запрашивали вход, проигнорируйте это письмо.",
+		},
+	}
+}
+
+func (s *MagicLinkCommandHandler) getUser() *users.User {
+	user, err := services.UsersRepository().GetByEmail(s.Command.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	return user
+}
+
+// 3-й уровень абстракции.
+
+func (s *MagicLinkCommandHandler) nonce() *string {
+	if s._nonce == nil {
+		s._nonce = generateRandomString()
+	}
+
+	return s._nonce
+}
+
+// Команда на вход по ссылке из письма.
+type MagicLinkConsumptionCommand struct {
+	// Токен ссылки для входа из письма.
+	Token string
+
+	// Одноразовое значение, сохраненное в браузере при запросе ссылки.
+	Nonce string
+
+	// IP адрес пользователя.
+	UserIp string
+
+	// Название устройства пользователя.
+	DeviceName string
+
+	// Значение заголовка User-Agent запроса пользователя.
+	UserAgent string
+}
+
+// Обработчик команды на вход по ссылке из письма.
+type MagicLinkConsumptionCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *MagicLinkConsumptionCommand
+
+	_token     *jwt.Jwt[magiclink.MagicLinkTokenPayload]
+	_magicLink *magiclinks.MagicLink
+	_user      *users.User
+}
+
+// Способы аутентификации (RFC 8176), используемые при входе по ссылке из письма.
+var magicLinkAmr = []string{"email"}
+
+// Обработать команду на вход по ссылке из письма.
+//
+// Переход по ссылке подтверждает владение адресом электронной почты, поэтому адрес отмечается как подтвержденный.
+func (s *MagicLinkConsumptionCommandHandler) Handle() *LoginResult {
+	s.validateNonceBySavedHash()
+	s.consumeMagicLink()
+
+	s.markEmailVerified()
+
+	if s.user().TotpEnabled {
+		return createMfaChallenge(s.user().Id, magicLinkAmr, s.Command.DeviceName)
+	}
+
+	return createSession(&SessionCreationCommand{
+		UserId:     s.user().Id,
+		UserIp:     s.Command.UserIp,
+		DeviceName: s.Command.DeviceName,
+		UserAgent:  s.Command.UserAgent,
+		Amr:        magicLinkAmr,
+	})
+}
+
+// 1-й уровень абстракции.
+
+func (s *MagicLinkConsumptionCommandHandler) validateNonceBySavedHash() {
+	if s.Command.Nonce == "" {
+		panicBecauseOfInvalidMagicLink()
+	}
+
+	err := services.OneTimeTokenDigester().Verify(s.Command.Nonce, s.magicLink().NonceHash)
+	if err != nil {
+		panicBecauseOfInvalidMagicLink()
+	}
+}
+
+func (s *MagicLinkConsumptionCommandHandler) consumeMagicLink() {
+	consumed, err := services.MagicLinksRepository().Consume(s.magicLink().Id)
+	if err != nil {
+		panic(err)
+	}
+
+	if !consumed {
+		panicBecauseOfInvalidMagicLink()
+	}
+}
+
+func (s *MagicLinkConsumptionCommandHandler) markEmailVerified() {
+	if s.user().EmailVerified {
+		return
+	}
+
+	_, err := services.UsersRepository().MarkEmailVerified(s.user().Id, s.user().Email)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// 2-й уровень абстракции.
+
+func (s *MagicLinkConsumptionCommandHandler) magicLink() *magiclinks.MagicLink {
+	if s._magicLink == nil {
+		s._magicLink = s.getMagicLink()
+	}
+
+	return s._magicLink
+}
+
+func (s *MagicLinkConsumptionCommandHandler) user() *users.User {
+	if s._user == nil {
+		s._user = s.getUser()
+	}
+
+	return s._user
+}
+
+// 3-й уровень абстракции.
+
+func (s *MagicLinkConsumptionCommandHandler) getMagicLink() *magiclinks.MagicLink {
+	magicLink, err := services.MagicLinksRepository().Get(s.token().Payload.Id)
+	if errors.Is(err, sql.ErrNoRows) {
+		panicBecauseOfInvalidMagicLink()
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	if magicLink.UserId != s.token().Payload.Subject {
+		panicBecauseOfInvalidMagicLink()
+	}
+
+	return magicLink
+}
+
+func (s *MagicLinkConsumptionCommandHandler) getUser() *users.User {
+	user, err := services.UsersRepository().Get(s.magicLink().UserId)
+	if err != nil {
+		panic(err)
+	}
+
+	return user
+}
+
+// 4-й уровень абстракции.
+
+func (s *MagicLinkConsumptionCommandHandler) token() *jwt.Jwt[magiclink.MagicLinkTokenPayload] {
+	if s._token == nil {
+		s._token = s.decodeToken()
+	}
+
+	return s._token
+}
+
+func (s *MagicLinkConsumptionCommandHandler) decodeToken() *jwt.Jwt[magiclink.MagicLinkTokenPayload] {
+	token, err := services.MagicLinkTokenIssuer().Decode(s.Command.Token)
+	if err != nil {
+		panicBecauseOfInvalidMagicLink()
+	}
+
+	return token
+}
+
+// Сгенерировать случайную строку из 32 байт в кодировке Base64URL.
+func generateRandomString() *string {
+	value := make([]byte, 32)
+
+	_, err := rand.Read(value)
+	if err != nil {
+		panic(err)
+	}
+
+	nonce := base64.RawURLEncoding.EncodeToString(value)
+
+	return &nonce
+}
+
+func panicBecauseOfInvalidMagicLink() {
+	panic(fmt.Errorf("%w: the magic link is invalid, expired, has already been used or was requested from another browser", ErrUnauthorized))
+}
@@ -0,0 +1,189 @@
+package logics
+
+import (
+	"fmt"
+	"goauth/data/users"
+	"goauth/logics/services"
+	"goauth/tokens/challenge"
+	"goauth/tokens/jwt"
+	"goauth/totp"
+	"time"
+)
+
+// Команда на завершение аутентификации проверкой второго фактора.
+type MfaVerificationCommand struct {
+	// Токен второго шага аутентификации, полученный при проверке первого фактора.
+	MfaChallengeToken string
+
+	// Код из приложения-аутентификатора.
+	Code string
+
+	// Код восстановления. Используется, если код из приложения-аутентификатора не указан.
+	RecoveryCode string
+
+	// IP адрес пользователя.
+	UserIp string
+
+	// Значение заголовка User-Agent запроса пользователя.
+	UserAgent string
+}
+
+// Обработчик команды на завершение аутентификации проверкой второго фактора.
+//
+// Токен второго шага используется однократно. Количество попыток предъявить второй фактор ограничено
+// как для одного второго шага, так и для всех вторых шагов пользователя за период учета попыток.
+type MfaVerificationCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *MfaVerificationCommand
+
+	_token *jwt.Jwt[challenge.MfaChallengeTokenPayload]
+	_user  *users.User
+}
+
+// Обработать команду на завершение аутентификации проверкой второго фактора.
+func (s *MfaVerificationCommandHandler) Handle() *LoginResult {
+	s.attemptChallenge()
+
+	if s.Command.Code != "" {
+		s.validateTotpCode()
+	} else {
+		s.useRecoveryCode()
+	}
+
+	s.consumeChallenge()
+
+	return createSession(&SessionCreationCommand{
+		UserId:     s.user().Id,
+		UserIp:     s.Command.UserIp,
+		DeviceName: s.token().Payload.DeviceName,
+		UserAgent:  s.Command.UserAgent,
+		Amr:        s.amr(),
+	})
+}
+
+// 1-й уровень абстракции.
+
+func (s *MfaVerificationCommandHandler) attemptChallenge() {
+	attempts, err := services.MfaChallengesRepository().CountAttemptsSince(s.token().Payload.Subject, time.Now().Add(-services.MFA_ATTEMPTS_WINDOW))
+	if err != nil {
+		panic(err)
+	}
+
+	if attempts >= services.MAX_MFA_ATTEMPTS_PER_USER {
+		panic(fmt.Errorf("%w: too many second factor attempts, try again later", ErrUnauthorized))
+	}
+
+	attempted, err := services.MfaChallengesRepository().Attempt(s.token().Payload.Id, services.MAX_MFA_CHALLENGE_ATTEMPTS)
+	if err != nil {
+		panic(err)
+	}
+
+	if !attempted {
+		panic(fmt.Errorf("%w: the MFA challenge has expired, has already been used or has too many attempts", ErrUnauthorized))
+	}
+}
+
+func (s *MfaVerificationCommandHandler) validateTotpCode() {
+	if !s.user().TotpEnabled {
+		panicBecauseOfInvalidSecondFactor()
+	}
+
+	step, valid, err := totp.Verify(s.user().TotpSecret, s.Command.Code, time.Now())
+	if err != nil {
+		panic(err)
+	}
+
+	if !valid {
+		panicBecauseOfInvalidSecondFactor()
+	}
+
+	used, err := services.UsersRepository().UseTotpStep(s.user().Id, step)
+	if err != nil {
+		panic(err)
+	}
+
+	if !used {
+		panicBecauseOfInvalidSecondFactor()
+	}
+}
+
+func (s *MfaVerificationCommandHandler) useRecoveryCode() {
+	if s.Command.RecoveryCode == "" {
+		panicBecauseOfInvalidSecondFactor()
+	}
+
+	used, err := services.RecoveryCodesRepository().Use(s.user().Id, recoveryCodeHash(s.Command.RecoveryCode))
+	if err != nil {
+		panic(err)
+	}
+
+	if !used {
+		panicBecauseOfInvalidSecondFactor()
+	}
+}
+
+// Способы аутентификации сеанса: способы первого фактора, "otp" для кода из приложения-аутентификатора и "mfa".
+// Код восстановления не является одноразовым паролем (RFC 8176, раздел 2), поэтому для него добавляется только "mfa".
+func (s *MfaVerificationCommandHandler) amr() []string {
+	amr := s.token().Payload.Amr
+	if s.Command.Code != "" {
+		amr = append(amr[:len(amr):len(amr)], "otp")
+	}
+
+	return append(amr[:len(amr):len(amr)], "mfa")
+}
+
+func (s *MfaVerificationCommandHandler) consumeChallenge() {
+	consumed, err := services.MfaChallengesRepository().Consume(s.token().Payload.Id)
+	if err != nil {
+		panic(err)
+	}
+
+	if !consumed {
+		panic(fmt.Errorf("%w: the MFA challenge has already been used", ErrUnauthorized))
+	}
+}
+
+// 2-й уровень абстракции.
+
+func (s *MfaVerificationCommandHandler) user() *users.User {
+	if s._user == nil {
+		s._user = s.getUser()
+	}
+
+	return s._user
+}
+
+func (s *MfaVerificationCommandHandler) token() *jwt.Jwt[challenge.MfaChallengeTokenPayload] {
+	if s._token == nil {
+		s._token = s.decodeToken()
+	}
+
+	return s._token
+}
+
+// 3-й уровень абстракции.
+
+func (s *MfaVerificationCommandHandler) getUser() *users.User {
+	user, err := services.UsersRepository().Get(s.token().Payload.Subject)
+	if err != nil {
+		panic(err)
+	}
+
+	return user
+}
+
+// 4-й уровень абстракции.
+
+func (s *MfaVerificationCommandHandler) decodeToken() *jwt.Jwt[challenge.MfaChallengeTokenPayload] {
+	token, err := services.MfaChallengeTokenIssuer().Decode(s.Command.MfaChallengeToken)
+	if err != nil {
+		panic(fmt.Errorf("%w: %w", ErrUnauthorized, err))
+	}
+
+	return token
+}
+
+func panicBecauseOfInvalidSecondFactor() {
+	panic(fmt.Errorf("%w: the second factor code is invalid or has already been used", ErrUnauthorized))
+}
@@ -0,0 +1,43 @@
+package logics
+
+import (
+	"goauth/tokens/challenge"
+	"goauth/tokens/jwt"
+	"slices"
+	"testing"
+)
+
+func TestMfaVerificationAmr(t *testing.T) {
+	cases := []struct {
+		name    string
+		command MfaVerificationCommand
+		want    []string
+	}{
+		{"totp code", MfaVerificationCommand{Code: "123456"}, []string{"pwd", "otp", "mfa"}},
+		{"recovery code", MfaVerificationCommand{RecoveryCode: "recovery"}, []string{"pwd", "mfa"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			firstFactor := make([]string, 1, 4)
+			firstFactor[0] = "pwd"
+
+			handler := MfaVerificationCommandHandler{
+				Command: &c.command,
+
+				_token: &jwt.Jwt[challenge.MfaChallengeTokenPayload]{
+					Payload: challenge.MfaChallengeTokenPayload{Amr: firstFactor},
+				},
+			}
+
+			amr := handler.amr()
+			if !slices.Equal(amr, c.want) {
+				t.Fatalf("amr() = %v, want %v", amr, c.want)
+			}
+
+			if !slices.Equal(firstFactor, []string{"pwd"}) || firstFactor[:2][1] != "" {
+				t.Fatalf("amr() modified the challenge token: %v", firstFactor[:2])
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package logics
+
+import (
+	"fmt"
+	"goauth/logics/services"
+	"goauth/secrets"
+)
+
+// Метаданные поставщика OpenID Connect (OpenID Connect Discovery 1.0, раздел 3).
+type OpenIdConfiguration struct {
+	// Издатель ID токенов: адрес сервиса `SERVICE_URL`. Совпадает с утверждением `iss` ID токенов.
+	Issuer string `json:"issuer"`
+
+	// Адрес конечной точки авторизации.
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+
+	// Адрес конечной точки токенов.
+	TokenEndpoint string `json:"token_endpoint"`
+
+	// Адрес конечной точки утверждений о пользователе.
+	UserInfoEndpoint string `json:"userinfo_endpoint"`
+
+	// Адрес набора ключей для проверки подписи токенов.
+	JwksUri string `json:"jwks_uri"`
+
+	// Поддерживаемые области доступа OpenID Connect.
+	ScopesSupported []string `json:"scopes_supported"`
+
+	// Поддерживаемые типы ответа конечной точки авторизации.
+	ResponseTypesSupported []string `json:"response_types_supported"`
+
+	// Поддерживаемые гранты.
+	GrantTypesSupported []string `json:"grant_types_supported"`
+
+	// Поддерживаемые типы субъектов.
+	SubjectTypesSupported []string `json:"subject_types_supported"`
+
+	// Алгоритмы подписи ID токенов.
+	IdTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+
+	// Поддерживаемые способы аутентификации клиентов на конечной точке токенов.
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+
+	// Поддерживаемые способы вычисления вызова PKCE.
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
+
+	// Утверждения, которые могут содержаться в ID токене и ответе конечной точки утверждений о пользователе.
+	ClaimsSupported []string `json:"claims_supported"`
+}
+
+// Обработчик запроса на получение метаданных поставщика OpenID Connect.
+type OpenIdConfigurationQueryHandler struct {
+}
+
+// Обработать запрос на получение метаданных поставщика OpenID Connect.
+//
+// Адреса конечных точек отсчитываются от `SERVICE_URL`. Если OpenID Connect не включен, метаданные не возвращаются.
+func (s *OpenIdConfigurationQueryHandler) Handle() *OpenIdConfiguration {
+	if !services.OPENID_CONNECT_IS_ENABLED {
+		panic(fmt.Errorf("%w: OpenID Connect is not configured", ErrNotFound))
+	}
+
+	issuer := services.IdTokenIssuer()
+
+	return &OpenIdConfiguration{
+		Issuer:                            issuer.Name,
+		AuthorizationEndpoint:             secrets.SERVICE_URL + "/oauth/authorize",
+		TokenEndpoint:                     secrets.SERVICE_URL + "/oauth/token",
+		UserInfoEndpoint:                  secrets.SERVICE_URL + "/userinfo",
+		JwksUri:                           secrets.SERVICE_URL + "/.well-known/jwks.json",
+		ScopesSupported:                   []string{OPENID_SCOPE, EMAIL_SCOPE},
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               supportedGrants,
+		SubjectTypesSupported:             []string{"public"},
+		IdTokenSigningAlgValuesSupported:  issuer.Algorithms,
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported:                   []string{"iss", "sub", "aud", "iat", "exp", "auth_time", "nonce", "at_hash", "amr", "email", "email_verified"},
+	}
+}
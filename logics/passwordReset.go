@@ -0,0 +1,282 @@
+package logics
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"goauth/data/resets"
+	"goauth/data/users"
+	"goauth/logics/services"
+	"goauth/secrets"
+	"goauth/tokens/opaque"
+	"net/url"
+	"time"
+)
+
+// Команда на отправку пользователю ссылки для сброса пароля.
+type ForgotPasswordCommand struct {
+	// Адрес электронной почты пользователя.
+	Email string
+}
+
+// Обработчик команды на отправку пользователю ссылки для сброса пароля.
+//
+// Если пользователь не найден, команда ничего не делает. Иначе запрос на сброс создается и письмо отправляется в фоне,
+// чтобы ни ответ, ни время ответа не выдавали, зарегистрирован ли адрес.
+type ForgotPasswordCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *ForgotPasswordCommand
+
+	_user *users.User
+
+	_createdReset *resets.PasswordReset
+	_token        *string
+}
+
+// Обработать команду на отправку пользователю ссылки для сброса пароля.
+func (s *ForgotPasswordCommandHandler) Handle() {
+	if s.user() == nil {
+		return
+	}
+
+	runInBackground("Не удалось отправить ссылку для сброса пароля", s.sendResetLink)
+}
+
+// 1-й уровень абстракции.
+
+func (s *ForgotPasswordCommandHandler) sendResetLink() {
+	s.deletePreviousResets()
+
+	s.saveTokenHash()
+
+	s.createNotificationCommandHandler().Handle()
+}
+
+func (s *ForgotPasswordCommandHandler) user() *users.User {
+	if s._user == nil {
+		s._user = s.getUser()
+	}
+
+	return s._user
+}
+
+// 2-й уровень абстракции.
+
+func (s *ForgotPasswordCommandHandler) deletePreviousResets() {
+	err := services.PasswordResetsRepository().DeleteByUser(s.user().Id)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (s *ForgotPasswordCommandHandler) saveTokenHash() {
+	err := services.PasswordResetsRepository().UpdateTokenHash(s.createdReset().Id, services.OneTimeTokenDigester().Digest(*s.token()))
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (s *ForgotPasswordCommandHandler) createNotificationCommandHandler() *NotificationCommandHandler {
+	return &NotificationCommandHandler{
+		Command: &NotificationCommand{
+			ReceiverEmail:  s.user().Email,
+			MessageSubject: "(shumilija/goauth) Сброс пароля",
+			MessageBody: "Для задания нового пароля перейдите по ссылке: " + secrets.APPLICATION_URL + "/reset-password?token=" + url.QueryEscape(*s.token()) +
+				". Ссылка действительна " + services.PASSWORD_RESET_TOKEN_LIFETIME.String() + ". Если вы не запрашивали сброс пароля, проигнорируйте это письмо.",
+		},
+	}
+}
+
+func (s *ForgotPasswordCommandHandler) getUser() *users.User {
+	user, err := services.UsersRepository().GetByEmail(s.Command.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	return user
+}
+
+// 3-й уровень абстракции.
+
+func (s *ForgotPasswordCommandHandler) token() *string {
+	if s._token == nil {
+		s._token = s.createToken()
+	}
+
+	return s._token
+}
+
+// 4-й уровень абстракции.
+
+func (s *ForgotPasswordCommandHandler) createToken() *string {
+	token, err := opaque.New(s.createdReset().Id)
+	if err != nil {
+		panic(err)
+	}
+
+	return &token
+}
+
+func (s *ForgotPasswordCommandHandler) createdReset() *resets.PasswordReset {
+	if s._createdReset == nil {
+		s._createdReset = s.createReset()
+	}
+
+	return s._createdReset
+}
+
+// 5-й уровень абстракции.
+
+func (s *ForgotPasswordCommandHandler) createReset() *resets.PasswordReset {
+	reset, err := services.PasswordResetsRepository().Create(resets.PasswordReset{
+		UserId:    s.user().Id,
+		ExpiresAt: time.Now().Add(services.PASSWORD_RESET_TOKEN_LIFETIME),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return reset
+}
+
+// Команда на задание нового пароля по токену сброса пароля.
+type PasswordResetCommand struct {
+	// Токен сброса пароля из письма.
+	Token string
+
+	// Новый пароль пользователя.
+	Password string
+}
+
+// Обработчик команды на задание нового пароля по токену сброса пароля.
+//
+// После задания пароля все сеансы пользователя завершаются.
+type PasswordResetCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *PasswordResetCommand
+
+	_reset *resets.PasswordReset
+	_user  *users.User
+}
+
+// Обработать команду на задание нового пароля по токену сброса пароля.
+func (s *PasswordResetCommandHandler) Handle() {
+	validatePassword(s.Command.Password)
+	s.validateTokenBySavedHash()
+	s.consumeReset()
+
+	s.updatePasswordHash()
+
+	s.deleteAllAuths()
+	s.deleteAllResets()
+
+	s.createNotificationCommandHandler().Handle()
+}
+
+// 1-й уровень абстракции.
+
+func (s *PasswordResetCommandHandler) validateTokenBySavedHash() {
+	err := services.OneTimeTokenDigester().Verify(s.Command.Token, s.reset().TokenHash)
+	if err != nil {
+		panicBecauseOfInvalidResetToken()
+	}
+}
+
+func (s *PasswordResetCommandHandler) consumeReset() {
+	consumed, err := services.PasswordResetsRepository().Consume(s.reset().Id)
+	if err != nil {
+		panic(err)
+	}
+
+	if !consumed {
+		panicBecauseOfInvalidResetToken()
+	}
+}
+
+func (s *PasswordResetCommandHandler) updatePasswordHash() {
+	hash, err := services.PasswordParameters().Hash(s.Command.Password)
+	if err != nil {
+		panic(err)
+	}
+
+	err = services.UsersRepository().UpdatePasswordHash(s.reset().UserId, hash)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (s *PasswordResetCommandHandler) deleteAllAuths() {
+	revokeDeletedAuths(services.AuthsRepository().DeleteByUser(s.reset().UserId))
+}
+
+func (s *PasswordResetCommandHandler) deleteAllResets() {
+	err := services.PasswordResetsRepository().DeleteByUser(s.reset().UserId)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (s *PasswordResetCommandHandler) createNotificationCommandHandler() *NotificationCommandHandler {
+	return &NotificationCommandHandler{
+		Command: &NotificationCommand{
+			ReceiverEmail:  s.user().Email,
+			MessageSubject: "(shumilija/goauth) WARNING",
+			MessageBody:    "Пароль учетной записи изменен, все сеансы завершены.",
+		},
+	}
+}
+
+// 2-й уровень абстракции.
+
+func (s *PasswordResetCommandHandler) reset() *resets.PasswordReset {
+	if s._reset == nil {
+		s._reset = s.getReset()
+	}
+
+	return s._reset
+}
+
+func (s *PasswordResetCommandHandler) user() *users.User {
+	if s._user == nil {
+		s._user = s.getUser()
+	}
+
+	return s._user
+}
+
+// 3-й уровень абстракции.
+
+func (s *PasswordResetCommandHandler) getReset() *resets.PasswordReset {
+	id, err := opaque.Id(s.Command.Token)
+	if err != nil {
+		panicBecauseOfInvalidResetToken()
+	}
+
+	reset, err := services.PasswordResetsRepository().Get(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		panicBecauseOfInvalidResetToken()
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	return reset
+}
+
+func (s *PasswordResetCommandHandler) getUser() *users.User {
+	user, err := services.UsersRepository().Get(s.reset().UserId)
+	if err != nil {
+		panic(err)
+	}
+
+	return user
+}
+
+func panicBecauseOfInvalidResetToken() {
+	panic(fmt.Errorf("%w: the password reset token is invalid, expired or has already been used", ErrInvalidRequest))
+}
@@ -5,12 +5,6 @@ import (
 	"goauth/data/auths"
 	"goauth/data/users"
 	"goauth/logics/services"
-	"goauth/tokens/access"
-	"goauth/tokens/jwt"
-	"goauth/tokens/refresh"
-	"time"
-
-	"golang.org/x/crypto/bcrypt"
 )
 
 // Команда на обновление аутентификации пользователя.
@@ -23,6 +17,9 @@ type RefreshCommand struct {
 
 	// IP адрес пользователя.
 	UserIp string
+
+	// Значение заголовка User-Agent запроса пользователя.
+	UserAgent string
 }
 
 // Результат обновления аутентификации пользователя.
@@ -39,10 +36,7 @@ type RefreshCommandHandler struct {
 	// Обрабатываемая команда.
 	Command *RefreshCommand
 
-	_previousAuth *auths.Auth
-
-	_previousAccessToken  *jwt.Jwt[access.AccessTokenPayload]
-	_previousRefreshToken *jwt.Jwt[refresh.RefreshTokenPayload]
+	_verification *TokenPairVerificationResult
 
 	_tokensCreationHandler *TokensCreationCommandHandler
 	_createdPairOfTokens   *TokensCreationResult
@@ -54,7 +48,8 @@ type RefreshCommandHandler struct {
 func (s *RefreshCommandHandler) Handle() *RefreshResult {
 	s.validateCommand()
 
-	s.deletePreviousAuth()
+	s.consumePreviousAuth()
+	s.revokePreviousAccessToken()
 
 	s.notifyUserIfAddressIsDifferent()
 
@@ -64,20 +59,33 @@ func (s *RefreshCommandHandler) Handle() *RefreshResult {
 // 1-й уровень абстракции.
 
 func (s *RefreshCommandHandler) validateCommand() {
-	s.panicIfTokensHaveDifferentIds()
-	s.panicIfRefreshTokenHasExpired()
-	s.validateRefreshTokenBySavedHash()
+	s.verification()
 }
 
-func (s *RefreshCommandHandler) deletePreviousAuth() {
-	err := services.AuthsRepository().Delete(s.previousAuth().Id)
+func (s *RefreshCommandHandler) consumePreviousAuth() {
+	consumed, err := services.AuthsRepository().Consume(s.verification().Auth.Id)
 	if err != nil {
 		panic(err)
 	}
+
+	if !consumed {
+		revokeAuthFamily(s.verification().Auth, s.Command.UserIp)
+		panic(fmt.Errorf("%w: REFRESH token has already been used", ErrUnauthorized))
+	}
+}
+
+func (s *RefreshCommandHandler) revokePreviousAccessToken() {
+	handler := AccessTokenRevocationCommandHandler{
+		Command: &AccessTokenRevocationCommand{
+			Auths: []auths.Auth{*s.verification().Auth},
+		},
+	}
+
+	handler.Handle()
 }
 
 func (s *RefreshCommandHandler) notifyUserIfAddressIsDifferent() {
-	if s.previousRefreshToken().Payload.UserIp != s.Command.UserIp {
+	if s.verification().UserIp != s.Command.UserIp {
 		s.createNotificationCommandHandler().Handle()
 	}
 }
@@ -91,31 +99,12 @@ func (s *RefreshCommandHandler) result() *RefreshResult {
 
 // 2-й уровень абстракции.
 
-func (s *RefreshCommandHandler) panicIfTokensHaveDifferentIds() {
-	if s.previousAccessToken().Payload.Id != s.previousRefreshToken().Payload.Id {
-		panic(fmt.Errorf("tokens have different ids"))
-	}
-}
-
-func (s *RefreshCommandHandler) panicIfRefreshTokenHasExpired() {
-	if s.previousRefreshToken().Payload.ExpirationTime < time.Now().Unix() {
-		panic(fmt.Errorf("REFRESH token has expired"))
-	}
-}
-
-func (s *RefreshCommandHandler) validateRefreshTokenBySavedHash() {
-	err := bcrypt.CompareHashAndPassword([]byte(s.previousAuth().RefreshTokenHash), s.encodedPreviousRefreshTokenBytesForBcrypt())
-	if err != nil {
-		panic(fmt.Errorf("REFRESH token does not match the token stored in the database"))
-	}
-}
-
-func (s *RefreshCommandHandler) previousAuth() *auths.Auth {
-	if s._previousAuth == nil {
-		s._previousAuth = s.getPreviousAuth()
+func (s *RefreshCommandHandler) verification() *TokenPairVerificationResult {
+	if s._verification == nil {
+		s._verification = verifyTokenPair(s.Command.AccessToken, s.Command.RefreshToken, s.Command.UserIp)
 	}
 
-	return s._previousAuth
+	return s._verification
 }
 
 func (s *RefreshCommandHandler) createdPairOfTokens() *TokensCreationResult {
@@ -138,27 +127,6 @@ func (s *RefreshCommandHandler) createNotificationCommandHandler() *Notification
 
 // 3-й уровень абстракции.
 
-func (s *RefreshCommandHandler) getPreviousAuth() *auths.Auth {
-	previousAuth, err := services.AuthsRepository().Get(s.previousRefreshToken().Payload.Id)
-	if err != nil {
-		panic(err)
-	}
-
-	return previousAuth
-}
-
-func (s *RefreshCommandHandler) previousRefreshToken() *jwt.Jwt[refresh.RefreshTokenPayload] {
-	if s._previousRefreshToken == nil {
-		s._previousRefreshToken = s.decodePreviousRefreshToken()
-	}
-
-	return s._previousRefreshToken
-}
-
-func (s *RefreshCommandHandler) encodedPreviousRefreshTokenBytesForBcrypt() []byte {
-	return []byte(s.Command.RefreshToken)[:MAX_BYTES_IN_VALUE_FOR_BCRYPT]
-}
-
 func (s *RefreshCommandHandler) createPairOfTokens() *TokensCreationResult {
 	return s.tokenCreationHandler().Handle()
 }
@@ -173,15 +141,6 @@ func (s *RefreshCommandHandler) user() *users.User {
 
 // 4-й уровень абстракции.
 
-func (s *RefreshCommandHandler) decodePreviousRefreshToken() *jwt.Jwt[refresh.RefreshTokenPayload] {
-	decodedPreviousRefreshToken, err := services.RefreshTokenIssuer().Decode(s.Command.RefreshToken)
-	if err != nil {
-		panic(err)
-	}
-
-	return decodedPreviousRefreshToken
-}
-
 func (s *RefreshCommandHandler) tokenCreationHandler() *TokensCreationCommandHandler {
 	if s._tokensCreationHandler == nil {
 		s._tokensCreationHandler = s.createTokenCreationHandler()
@@ -191,7 +150,7 @@ func (s *RefreshCommandHandler) tokenCreationHandler() *TokensCreationCommandHan
 }
 
 func (s *RefreshCommandHandler) getUser() *users.User {
-	user, err := services.UsersRepository().Get(s.previousAccessToken().Payload.Subject)
+	user, err := services.UsersRepository().Get(s.verification().Auth.UserId)
 	if err != nil {
 		panic(err)
 	}
@@ -202,33 +161,22 @@ func (s *RefreshCommandHandler) getUser() *users.User {
 // 5-й уровень абстракции.
 
 func (s *RefreshCommandHandler) createTokenCreationHandler() *TokensCreationCommandHandler {
+	previousAuth := s.verification().Auth
+
 	tokenCreationHandler := TokensCreationCommandHandler{
 		Command: &TokensCreationCommand{
-			UserId: s.previousAccessToken().Payload.Subject,
-			UserIp: s.Command.UserIp,
+			UserId:           previousAuth.UserId,
+			UserIp:           s.Command.UserIp,
+			Scope:            s.verification().AccessToken.Payload.Scope,
+			ClientId:         s.verification().AccessToken.Payload.ClientId,
+			ParentAuthId:     previousAuth.Id,
+			AuthFamilyId:     previousAuth.FamilyId,
+			DeviceName:       previousAuth.DeviceName,
+			UserAgent:        s.Command.UserAgent,
+			SessionCreatedAt: previousAuth.CreatedAt,
+			Amr:              previousAuth.Amr,
 		},
 	}
 
 	return &tokenCreationHandler
 }
-
-// 6-й уровень абстракции.
-
-func (s *RefreshCommandHandler) previousAccessToken() *jwt.Jwt[access.AccessTokenPayload] {
-	if s._previousAccessToken == nil {
-		s._previousAccessToken = s.decodePreviousAccessToken()
-	}
-
-	return s._previousAccessToken
-}
-
-// 7-й уровень абстракции.
-
-func (s *RefreshCommandHandler) decodePreviousAccessToken() *jwt.Jwt[access.AccessTokenPayload] {
-	decodedPreviousAccessToken, err := services.AccessTokenIssuer().Decode(s.Command.AccessToken)
-	if err != nil {
-		panic(err)
-	}
-
-	return decodedPreviousAccessToken
-}
@@ -0,0 +1,165 @@
+package logics
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"goauth/data/auths"
+	"goauth/logics/services"
+	"goauth/tokens/jwt"
+	"goauth/tokens/opaque"
+	"goauth/tokens/refresh"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Команда на проверку REFRESH токена, предъявленного пользователем.
+type RefreshTokenVerificationCommand struct {
+	// REFRESH токен пользователя.
+	RefreshToken string
+}
+
+// Результат проверки REFRESH токена.
+type RefreshTokenVerificationResult struct {
+	// Запись в таблице AUTHS, для которой был выдан токен. Токен мог быть уже использован (поле ConsumedAt).
+	Auth *auths.Auth
+
+	// IP адрес, под которым пользователь получил токен.
+	UserIp string
+}
+
+// Обработчик команды на проверку REFRESH токена, предъявленного пользователем.
+//
+// Проверяет подпись и утверждения токена, срок действия и хэш записи в таблице AUTHS.
+// Повторное использование токена не проверяется.
+type RefreshTokenVerificationCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *RefreshTokenVerificationCommand
+
+	_auth *auths.Auth
+
+	_token *jwt.Jwt[refresh.RefreshTokenPayload]
+}
+
+// Обработать команду на проверку REFRESH токена, предъявленного пользователем.
+func (s *RefreshTokenVerificationCommandHandler) Handle() *RefreshTokenVerificationResult {
+	s.panicIfTokenHasExpired()
+	s.validateTokenBySavedHash()
+
+	return s.result()
+}
+
+// 1-й уровень абстракции.
+
+func (s *RefreshTokenVerificationCommandHandler) panicIfTokenHasExpired() {
+	if !time.Now().Before(s.auth().ExpiresAt) {
+		panic(fmt.Errorf("%w: REFRESH token has expired", ErrUnauthorized))
+	}
+}
+
+func (s *RefreshTokenVerificationCommandHandler) validateTokenBySavedHash() {
+	var err error
+
+	if opaque.IsDigest(s.auth().RefreshTokenHash) {
+		err = services.RefreshTokenDigester().Verify(s.Command.RefreshToken, s.auth().RefreshTokenHash)
+	} else {
+		err = bcrypt.CompareHashAndPassword([]byte(s.auth().RefreshTokenHash), s.encodedTokenBytesForBcrypt())
+	}
+
+	if err != nil {
+		panic(fmt.Errorf("%w: REFRESH token does not match the token stored in the database", ErrUnauthorized))
+	}
+}
+
+func (s *RefreshTokenVerificationCommandHandler) result() *RefreshTokenVerificationResult {
+	return &RefreshTokenVerificationResult{
+		Auth:   s.auth(),
+		UserIp: s.userIp(),
+	}
+}
+
+// 2-й уровень абстракции.
+
+func (s *RefreshTokenVerificationCommandHandler) auth() *auths.Auth {
+	if s._auth == nil {
+		s._auth = s.getAuth()
+	}
+
+	return s._auth
+}
+
+func (s *RefreshTokenVerificationCommandHandler) userIp() string {
+	if opaque.IsOpaque(s.Command.RefreshToken) {
+		return s.auth().UserIp
+	}
+
+	return s.token().Payload.UserIp
+}
+
+func (s *RefreshTokenVerificationCommandHandler) encodedTokenBytesForBcrypt() []byte {
+	return []byte(s.Command.RefreshToken)[:min(len(s.Command.RefreshToken), MAX_BYTES_IN_VALUE_FOR_BCRYPT)]
+}
+
+// 3-й уровень абстракции.
+
+func (s *RefreshTokenVerificationCommandHandler) getAuth() *auths.Auth {
+	auth, err := services.AuthsRepository().Get(s.tokenId())
+	if errors.Is(err, sql.ErrNoRows) {
+		panic(fmt.Errorf("%w: REFRESH token has been revoked", ErrUnauthorized))
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	return auth
+}
+
+// 4-й уровень абстракции.
+
+func (s *RefreshTokenVerificationCommandHandler) tokenId() int32 {
+	if !opaque.IsOpaque(s.Command.RefreshToken) {
+		return s.token().Payload.Id
+	}
+
+	id, err := opaque.Id(s.Command.RefreshToken)
+	if err != nil {
+		panic(fmt.Errorf("%w: %w", ErrUnauthorized, err))
+	}
+
+	return id
+}
+
+func (s *RefreshTokenVerificationCommandHandler) token() *jwt.Jwt[refresh.RefreshTokenPayload] {
+	if s._token == nil {
+		s._token = s.decodeToken()
+	}
+
+	return s._token
+}
+
+// 5-й уровень абстракции.
+
+func (s *RefreshTokenVerificationCommandHandler) decodeToken() *jwt.Jwt[refresh.RefreshTokenPayload] {
+	if s.Command.RefreshToken == "" {
+		panic(fmt.Errorf("%w: REFRESH token is not specified", ErrUnauthorized))
+	}
+
+	token, err := services.RefreshTokenIssuer().Decode(s.Command.RefreshToken)
+	if err != nil {
+		panic(fmt.Errorf("%w: %w", ErrUnauthorized, err))
+	}
+
+	return token
+}
+
+func verifyRefreshToken(refreshToken string) *RefreshTokenVerificationResult {
+	handler := RefreshTokenVerificationCommandHandler{
+		Command: &RefreshTokenVerificationCommand{
+			RefreshToken: refreshToken,
+		},
+	}
+
+	return handler.Handle()
+}
@@ -0,0 +1,130 @@
+package logics
+
+import (
+	"errors"
+	"fmt"
+	"goauth/data/users"
+	"goauth/logics/services"
+	"goauth/secrets"
+	"goauth/tokens/verification"
+	"net/mail"
+	"net/url"
+	"unicode/utf8"
+)
+
+// Команда на регистрацию пользователя.
+type RegistrationCommand struct {
+	// Адрес электронной почты пользователя.
+	Email string
+
+	// Пароль пользователя.
+	Password string
+}
+
+// Обработчик команды на регистрацию пользователя.
+//
+// Если адрес уже занят, вместо письма с подтверждением отправляет письмо о существующей учетной записи,
+// чтобы ответ не выдавал, зарегистрирован ли адрес.
+type RegistrationCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *RegistrationCommand
+
+	_passwordHash *string
+}
+
+// Обработать команду на регистрацию пользователя.
+func (s *RegistrationCommandHandler) Handle() {
+	s.validateCommand()
+
+	user, err := services.UsersRepository().Create(s.email(), *s.passwordHash())
+	if errors.Is(err, users.ErrEmailTaken) {
+		s.createAccountExistsNotificationCommandHandler().Handle()
+		return
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	sendEmailVerification(user)
+}
+
+// 1-й уровень абстракции.
+
+func (s *RegistrationCommandHandler) validateCommand() {
+	s.email()
+	validatePassword(s.Command.Password)
+}
+
+func (s *RegistrationCommandHandler) createAccountExistsNotificationCommandHandler() *NotificationCommandHandler {
+	return &NotificationCommandHandler{
+		Command: &NotificationCommand{
+			ReceiverEmail:  s.email(),
+			MessageSubject: "(shumilija/goauth) Регистрация",
+			MessageBody:    "Выполнена попытка зарегистрироваться с этим адресом, но учетная запись с ним уже существует. Если это были вы, войдите с ранее заданным паролем.",
+		},
+	}
+}
+
+// 2-й уровень абстракции.
+
+func (s *RegistrationCommandHandler) email() string {
+	if len(s.Command.Email) > MAX_EMAIL_LENGTH_IN_BYTES {
+		panic(fmt.Errorf("%w: the email must not exceed %d bytes", ErrInvalidRequest, MAX_EMAIL_LENGTH_IN_BYTES))
+	}
+
+	address, err := mail.ParseAddress(s.Command.Email)
+	if err != nil || address.Address != s.Command.Email {
+		panic(fmt.Errorf("%w: the email is not valid", ErrInvalidRequest))
+	}
+
+	return address.Address
+}
+
+func (s *RegistrationCommandHandler) passwordHash() *string {
+	if s._passwordHash == nil {
+		s._passwordHash = s.hashPassword()
+	}
+
+	return s._passwordHash
+}
+
+// 3-й уровень абстракции.
+
+func (s *RegistrationCommandHandler) hashPassword() *string {
+	hash, err := services.PasswordParameters().Hash(s.Command.Password)
+	if err != nil {
+		panic(err)
+	}
+
+	return &hash
+}
+
+// Проверить, что пароль удовлетворяет требованиям к длине.
+func validatePassword(password string) {
+	if utf8.RuneCountInString(password) < MIN_PASSWORD_LENGTH {
+		panic(fmt.Errorf("%w: the password must contain at least %d characters", ErrInvalidRequest, MIN_PASSWORD_LENGTH))
+	}
+
+	if len(password) > MAX_PASSWORD_LENGTH_IN_BYTES {
+		panic(fmt.Errorf("%w: the password must not exceed %d bytes", ErrInvalidRequest, MAX_PASSWORD_LENGTH_IN_BYTES))
+	}
+}
+
+// Отправить пользователю письмо со ссылкой для подтверждения адреса электронной почты.
+func sendEmailVerification(user *users.User) {
+	token, err := services.EmailVerificationTokenIssuer().Encode(verification.New(services.EmailVerificationTokenIssuer(), user.Id, user.Email))
+	if err != nil {
+		panic(err)
+	}
+
+	handler := NotificationCommandHandler{
+		Command: &NotificationCommand{
+			ReceiverEmail:  user.Email,
+			MessageSubject: "(shumilija/goauth) Подтверждение адреса",
+			MessageBody:    "Для подтверждения адреса электронной почты перейдите по ссылке: " + secrets.APPLICATION_URL + "/verify-email?token=" + url.QueryEscape(token),
+		},
+	}
+
+	handler.Handle()
+}
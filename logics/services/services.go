@@ -1,32 +1,326 @@
 package services
 
 import (
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"goauth/data"
+	"goauth/data/authorizationcodes"
 	"goauth/data/auths"
+	"goauth/data/clients"
+	"goauth/data/clienttokens"
+	"goauth/data/credentials"
+	"goauth/data/magiclinks"
+	"goauth/data/mfachallenges"
+	"goauth/data/recoverycodes"
+	"goauth/data/resets"
+	"goauth/data/revocations"
 	"goauth/data/users"
+	"goauth/data/webauthnchallenges"
+	"goauth/passwords"
 	"goauth/secrets"
 	"goauth/tokens/access"
+	"goauth/tokens/ceremony"
+	"goauth/tokens/challenge"
+	"goauth/tokens/idtoken"
+	"goauth/tokens/jwt"
+	"goauth/tokens/magiclink"
+	"goauth/tokens/opaque"
 	"goauth/tokens/refresh"
+	"goauth/tokens/verification"
+	"goauth/webauthn"
+	"net/url"
+	"os"
+	"sync"
+	"time"
 )
 
+var accessTokenKeyring = sync.OnceValue(func() *jwt.Keyring {
+	return keyring(secrets.ACCESS_TOKEN_KEY_GRACE_PERIOD_IN_SECONDS, secrets.ACCESS_TOKEN_KEYRING_FILE, secrets.ACCESS_TOKEN_ALGORITHM, secrets.ACCESS_TOKEN_KEY)
+})
+
+var refreshTokenKeyring = sync.OnceValue(func() *jwt.Keyring {
+	return keyring(secrets.REFRESH_TOKEN_KEY_GRACE_PERIOD_IN_SECONDS, secrets.REFRESH_TOKEN_KEYRING_FILE, secrets.REFRESH_TOKEN_ALGORITHM, secrets.REFRESH_TOKEN_KEY)
+})
+
+var idTokenKeyring = sync.OnceValue(func() *jwt.Keyring {
+	if !OPENID_CONNECT_IS_ENABLED {
+		return &jwt.Keyring{}
+	}
+
+	result := keyring(secrets.ID_TOKEN_KEY_GRACE_PERIOD_IN_SECONDS, secrets.ID_TOKEN_KEYRING_FILE, secrets.ID_TOKEN_ALGORITHM, secrets.ID_TOKEN_KEY)
+
+	err := checkIdTokenKeys(result)
+	if err != nil {
+		panic(err)
+	}
+
+	return result
+})
+
+// Выдавать ID токены OpenID Connect. Включается ключом `ID_TOKEN_KEY` или файлом `ID_TOKEN_KEYRING_FILE`.
+const OPENID_CONNECT_IS_ENABLED = secrets.ID_TOKEN_KEY != "" || secrets.ID_TOKEN_KEYRING_FILE != ""
+
+// Выдавать непрозрачные REFRESH токены вместо JWT токенов.
+const REFRESH_TOKENS_ARE_OPAQUE = secrets.REFRESH_TOKEN_FORMAT == "opaque"
+
+// Максимальное количество одновременных сеансов пользователя. При превышении завершаются самые старые сеансы. 0 снимает ограничение.
+const MAX_SESSIONS_PER_USER = 10
+
+// Время жизни токена сброса пароля.
+const PASSWORD_RESET_TOKEN_LIFETIME = 30 * time.Minute
+
+// Время жизни ссылки для входа, отправляемой по электронной почте.
+const MAGIC_LINK_TOKEN_LIFETIME = 15 * time.Minute
+
+// Время, за которое после проверки первого фактора нужно завершить аутентификацию проверкой второго.
+const MFA_CHALLENGE_LIFETIME = 5 * time.Minute
+
+// Максимальное количество попыток предъявить второй фактор в рамках одного второго шага аутентификации.
+const MAX_MFA_CHALLENGE_ATTEMPTS = 5
+
+// Максимальное количество попыток пользователя предъявить второй фактор за `MFA_ATTEMPTS_WINDOW`
+// во всех вторых шагах аутентификации. Ограничивает перебор кодов с повторным прохождением первого шага.
+const MAX_MFA_ATTEMPTS_PER_USER = 20
+
+// Период, за который учитываются попытки пользователя предъявить второй фактор.
+const MFA_ATTEMPTS_WINDOW = 15 * time.Minute
+
+// Максимальное время с начала сеанса, в течение которого разрешено подключать второй фактор TOTP.
+// Защищает от подключения второго фактора по похищенному токену давно начатого сеанса.
+const MAX_SESSION_AGE_FOR_TOTP_ENROLLMENT = 10 * time.Minute
+
+// Время жизни кода авторизации OAuth 2.0.
+const AUTHORIZATION_CODE_LIFETIME = 60 * time.Second
+
+// Роль пользователя, которому разрешено управлять клиентами OAuth 2.0.
+const ADMIN_ROLE = "admin"
+
+// Политика аутентификации пользователей, не подтвердивших адрес электронной почты.
+type UnverifiedUsersPolicy int
+
+const (
+	// Пользователи с неподтвержденным адресом аутентифицируются наравне с остальными.
+	AllowUnverifiedUsers UnverifiedUsersPolicy = iota
+
+	// Пользователям с неподтвержденным адресом отказывается в аутентификации.
+	DenyUnverifiedUsers
+)
+
+// Политика аутентификации пользователей, не подтвердивших адрес электронной почты.
+const UNVERIFIED_USERS_POLICY = DenyUnverifiedUsers
+
+// Функция, дополняющая утверждения ACCESS токена указанного пользователя перед его выдачей.
+type AccessTokenClaimsEnricher func(user *users.User, payload *access.AccessTokenPayload) error
+
+// Функции, дополняющие утверждения ACCESS токенов перед их выдачей. Выполняются в порядке следования.
+var AccessTokenClaimsEnrichers = []AccessTokenClaimsEnricher{}
+
 // Настроенный для приложения издатель ACCESS токенов.
 func AccessTokenIssuer() access.Issuer {
 	return access.Issuer{
-		Name:                   secrets.TOKEN_ISSUER_NAME,
-		Key:                    secrets.ACCESS_TOKEN_KEY,
-		TokenLifeTimeInMinutes: 15,
+		Name:       secrets.TOKEN_ISSUER_NAME,
+		Lifetime:   15 * time.Minute,
+		Audience:   []string{secrets.ACCESS_TOKEN_AUDIENCE},
+		Keys:       accessTokenKeyring(),
+		Algorithms: accessTokenKeyring().Algorithms(),
+		Validator:  accessTokenValidator(),
+	}
+}
+
+// Настроенный для приложения издатель ID токенов OpenID Connect.
+//
+// Токены подписываются отдельным асимметричным ключом, чтобы клиенты проверяли их по открытым ключам из `/.well-known/jwks.json`.
+// Издателем указывается `SERVICE_URL`: клиенты OpenID Connect сравнивают его с адресом, по которому получены метаданные поставщика.
+// Получателем токена указывается идентификатор клиента, поэтому ID токен не принимается вместо ACCESS токена.
+func IdTokenIssuer() idtoken.Issuer {
+	return idtoken.Issuer{
+		Name:       secrets.SERVICE_URL,
+		Lifetime:   15 * time.Minute,
+		Keys:       idTokenKeyring(),
+		Algorithms: idTokenKeyring().Algorithms(),
+		Validator: jwt.Validator{
+			Issuer: secrets.SERVICE_URL,
+			Skew:   Validator().Skew,
+		},
 	}
 }
 
 // Настроенный для приложения издатель REFRESH токенов.
 func RefreshTokenIssuer() refresh.Issuer {
 	return refresh.Issuer{
-		Name:                 secrets.TOKEN_ISSUER_NAME,
-		Key:                  secrets.REFRESH_TOKEN_KEY,
-		TokenLifeTimeInHours: 24,
+		Name:       secrets.TOKEN_ISSUER_NAME,
+		Lifetime:   24 * time.Hour,
+		Keys:       refreshTokenKeyring(),
+		Algorithms: refreshTokenKeyring().Algorithms(),
+		Validator:  Validator(),
+		Encrypter:  refreshTokenEncrypter(),
+	}
+}
+
+// Настроенный для приложения издатель токенов подтверждения адреса электронной почты.
+//
+// Токены подписываются ключом ACCESS токенов и отличаются от них получателем.
+func EmailVerificationTokenIssuer() verification.Issuer {
+	validator := Validator()
+	validator.Audience = []string{verification.AUDIENCE}
+
+	return verification.Issuer{
+		Name:       secrets.TOKEN_ISSUER_NAME,
+		Lifetime:   24 * time.Hour,
+		Audience:   []string{verification.AUDIENCE},
+		Keys:       accessTokenKeyring(),
+		Algorithms: accessTokenKeyring().Algorithms(),
+		Validator:  validator,
+	}
+}
+
+// Настроенный для приложения издатель токенов второго шага аутентификации.
+//
+// Токены подписываются ключом ACCESS токенов и отличаются от них получателем.
+func MfaChallengeTokenIssuer() challenge.Issuer {
+	validator := Validator()
+	validator.Audience = []string{challenge.AUDIENCE}
+
+	return challenge.Issuer{
+		Name:       secrets.TOKEN_ISSUER_NAME,
+		Lifetime:   MFA_CHALLENGE_LIFETIME,
+		Audience:   []string{challenge.AUDIENCE},
+		Keys:       accessTokenKeyring(),
+		Algorithms: accessTokenKeyring().Algorithms(),
+		Validator:  validator,
+	}
+}
+
+// Настроенный для приложения издатель токенов ссылок для входа.
+//
+// Токены подписываются ключом ACCESS токенов и отличаются от них получателем.
+func MagicLinkTokenIssuer() magiclink.Issuer {
+	validator := Validator()
+	validator.Audience = []string{magiclink.AUDIENCE}
+
+	return magiclink.Issuer{
+		Name:       secrets.TOKEN_ISSUER_NAME,
+		Lifetime:   MAGIC_LINK_TOKEN_LIFETIME,
+		Audience:   []string{magiclink.AUDIENCE},
+		Keys:       accessTokenKeyring(),
+		Algorithms: accessTokenKeyring().Algorithms(),
+		Validator:  validator,
+	}
+}
+
+// Настроенный для приложения издатель токенов церемонии регистрации учетных данных WebAuthn.
+//
+// Токены подписываются ключом ACCESS токенов и отличаются от них получателем.
+func WebAuthnRegistrationTokenIssuer() ceremony.Issuer {
+	return ceremonyTokenIssuer(ceremony.REGISTRATION_AUDIENCE)
+}
+
+// Настроенный для приложения издатель токенов церемонии аутентификации WebAuthn.
+//
+// Токены подписываются ключом ACCESS токенов и отличаются от них получателем.
+func WebAuthnAuthenticationTokenIssuer() ceremony.Issuer {
+	return ceremonyTokenIssuer(ceremony.AUTHENTICATION_AUDIENCE)
+}
+
+// Настроенная для приложения проверяющая сторона WebAuthn: доменное имя и источник берутся из адреса приложения.
+func RelyingParty() webauthn.RelyingParty {
+	applicationUrl, err := url.Parse(secrets.APPLICATION_URL)
+	if err != nil {
+		panic(err)
+	}
+
+	return webauthn.RelyingParty{
+		Id:     applicationUrl.Hostname(),
+		Name:   secrets.TOKEN_ISSUER_NAME,
+		Origin: applicationUrl.Scheme + "://" + applicationUrl.Host,
+	}
+}
+
+// Настроенное для приложения средство вычисления дайджестов REFRESH токенов для хранения в таблице AUTHS.
+func RefreshTokenDigester() opaque.Digester {
+	return opaque.Digester{
+		Key: []byte(secrets.REFRESH_TOKEN_DIGEST_KEY),
+	}
+}
+
+// Настроенные для приложения параметры хэширования паролей. Хэши, вычисленные с другими параметрами, пересчитываются при аутентификации.
+func PasswordParameters() passwords.Parameters {
+	return passwords.DefaultParameters
+}
+
+// Настроенное для приложения средство вычисления дайджестов одноразовых непрозрачных токенов, отправляемых по электронной почте.
+func OneTimeTokenDigester() opaque.Digester {
+	return opaque.Digester{
+		Key: []byte(secrets.ONE_TIME_TOKEN_DIGEST_KEY),
+	}
+}
+
+// Настроенное для приложения средство вычисления дайджестов секретов клиентов OAuth 2.0 для хранения в таблице CLIENTS.
+func ClientSecretDigester() opaque.Digester {
+	return opaque.Digester{
+		Key: []byte(secrets.CLIENT_SECRET_DIGEST_KEY),
+	}
+}
+
+// Настроенное для приложения средство проверки зарегистрированных утверждений токенов.
+func Validator() jwt.Validator {
+	return jwt.Validator{
+		Issuer: secrets.TOKEN_ISSUER_NAME,
+		Skew:   30 * time.Second,
+	}
+}
+
+var refreshTokenEncrypter = sync.OnceValue(func() *jwt.Encrypter {
+	if secrets.REFRESH_TOKEN_ENCRYPTION_ALGORITHM == "" {
+		return nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(secrets.REFRESH_TOKEN_ENCRYPTION_KEY)
+	if err != nil {
+		panic(err)
+	}
+
+	return &jwt.Encrypter{
+		Algorithm: secrets.REFRESH_TOKEN_ENCRYPTION_ALGORITHM,
+		Key:       key,
+	}
+})
+
+func ceremonyTokenIssuer(audience string) ceremony.Issuer {
+	validator := Validator()
+	validator.Audience = []string{audience}
+
+	return ceremony.Issuer{
+		Name:       secrets.TOKEN_ISSUER_NAME,
+		Lifetime:   webauthn.TIMEOUT_IN_MILLISECONDS * time.Millisecond,
+		Audience:   []string{audience},
+		Keys:       accessTokenKeyring(),
+		Algorithms: accessTokenKeyring().Algorithms(),
+		Validator:  validator,
 	}
 }
 
+func accessTokenValidator() jwt.Validator {
+	result := Validator()
+	result.Audience = []string{secrets.ACCESS_TOKEN_AUDIENCE}
+
+	return result
+}
+
+// Перечитать наборы ключей подписи ACCESS, REFRESH и ID токенов из файлов `ACCESS_TOKEN_KEYRING_FILE`, `REFRESH_TOKEN_KEYRING_FILE`
+// и `ID_TOKEN_KEYRING_FILE`.
+//
+// Наборы, для которых файл не указан, не изменяются. Если файл не удалось прочитать или набор ключей ID токенов содержит ключи HMAC,
+// прежний набор продолжает использоваться.
+func ReloadTokenKeys() error {
+	return errors.Join(
+		loadKeyring(accessTokenKeyring(), secrets.ACCESS_TOKEN_KEYRING_FILE),
+		loadKeyring(refreshTokenKeyring(), secrets.REFRESH_TOKEN_KEYRING_FILE),
+		loadKeyring(idTokenKeyring(), secrets.ID_TOKEN_KEYRING_FILE, checkIdTokenKeys),
+	)
+}
+
 // Настроенный для приложения репозиторий для таблицы USERS.
 func UsersRepository() users.Repository {
 	return users.Repository{
@@ -41,6 +335,86 @@ func AuthsRepository() auths.Repository {
 	}
 }
 
+var memoryRevokedTokensStore = sync.OnceValue(func() *revocations.Memory {
+	return revocations.NewMemory()
+})
+
+// Настроенное для приложения хранилище отозванных ACCESS токенов.
+//
+// Хранилище в памяти процесса подходит только для единственного экземпляра сервиса.
+func RevokedTokensStore() revocations.Store {
+	if secrets.REVOKED_TOKENS_STORE == "postgres" {
+		return revocations.Repository{
+			Context: Context(),
+		}
+	}
+
+	return memoryRevokedTokensStore()
+}
+
+// Настроенный для приложения репозиторий для таблицы PASSWORD_RESETS.
+func PasswordResetsRepository() resets.Repository {
+	return resets.Repository{
+		Context: Context(),
+	}
+}
+
+// Настроенный для приложения репозиторий для таблицы RECOVERY_CODES.
+func RecoveryCodesRepository() recoverycodes.Repository {
+	return recoverycodes.Repository{
+		Context: Context(),
+	}
+}
+
+// Настроенный для приложения репозиторий для таблицы MAGIC_LINKS.
+func MagicLinksRepository() magiclinks.Repository {
+	return magiclinks.Repository{
+		Context: Context(),
+	}
+}
+
+// Настроенный для приложения репозиторий для таблицы MFA_CHALLENGES.
+func MfaChallengesRepository() mfachallenges.Repository {
+	return mfachallenges.Repository{
+		Context: Context(),
+	}
+}
+
+// Настроенный для приложения репозиторий для таблицы CLIENTS.
+func ClientsRepository() clients.Repository {
+	return clients.Repository{
+		Context: Context(),
+	}
+}
+
+// Настроенный для приложения репозиторий для таблицы CLIENT_TOKENS.
+func ClientTokensRepository() clienttokens.Repository {
+	return clienttokens.Repository{
+		Context: Context(),
+	}
+}
+
+// Настроенный для приложения репозиторий для таблицы AUTHORIZATION_CODES.
+func AuthorizationCodesRepository() authorizationcodes.Repository {
+	return authorizationcodes.Repository{
+		Context: Context(),
+	}
+}
+
+// Настроенный для приложения репозиторий для таблицы WEBAUTHN_CREDENTIALS.
+func CredentialsRepository() credentials.Repository {
+	return credentials.Repository{
+		Context: Context(),
+	}
+}
+
+// Настроенный для приложения репозиторий для таблицы WEBAUTHN_CHALLENGES.
+func WebAuthnChallengesRepository() webauthnchallenges.Repository {
+	return webauthnchallenges.Repository{
+		Context: Context(),
+	}
+}
+
 // Настроенный для приложения контекст подключения к БД.
 func Context() data.Context {
 	return data.Context{
@@ -49,3 +423,75 @@ func Context() data.Context {
 		DbName:   secrets.DB_NAME,
 	}
 }
+
+func key(algorithm string, value string) jwt.Key {
+	result, err := jwt.NewKey(algorithm, value)
+	if err != nil {
+		panic(err)
+	}
+
+	return result
+}
+
+// Создать набор ключей из файла конфигурации или, если файл не указан, из единственного ключа.
+func keyring(gracePeriodInSeconds int, file string, algorithm string, value string) *jwt.Keyring {
+	gracePeriod := time.Duration(gracePeriodInSeconds) * time.Second
+
+	if file == "" {
+		return jwt.NewKeyring(gracePeriod, key(algorithm, value))
+	}
+
+	result := &jwt.Keyring{GracePeriod: gracePeriod}
+
+	err := loadKeyring(result, file)
+	if err != nil {
+		panic(err)
+	}
+
+	return result
+}
+
+// Загрузить набор ключей из файла. Новый набор заменяет прежний, только если проходит указанные проверки.
+func loadKeyring(keyring *jwt.Keyring, file string, checks ...func(*jwt.Keyring) error) error {
+	if file == "" {
+		return nil
+	}
+
+	value, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	candidate := &jwt.Keyring{GracePeriod: keyring.GracePeriod}
+
+	err = candidate.Load(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	for _, check := range checks {
+		err = check(candidate)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+
+	err = keyring.Load(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	return nil
+}
+
+// Убедиться, что ключи подписи ID токенов асимметричные. Клиенты проверяют подпись ID токенов сами, а секрет HMAC,
+// выданный клиенту для проверки, позволил бы ему подделать ID токен для другого клиента или ACCESS токен.
+func checkIdTokenKeys(keyring *jwt.Keyring) error {
+	for _, key := range keyring.VerificationKeys() {
+		if jwt.IsSymmetric(key.Algorithm) {
+			return fmt.Errorf("the ID token key %s uses the symmetric algorithm %s, OpenID Connect requires an asymmetric algorithm such as RS256", key.Id, key.Algorithm)
+		}
+	}
+
+	return nil
+}
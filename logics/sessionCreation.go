@@ -0,0 +1,139 @@
+package logics
+
+import (
+	"goauth/data/auths"
+	"goauth/logics/services"
+)
+
+// Команда на начало нового сеанса пользователя, прошедшего аутентификацию.
+type SessionCreationCommand struct {
+	// Идентификатор пользователя, которому требуется выдать пару токенов.
+	UserId int32
+
+	// IP адрес пользователя.
+	UserIp string
+
+	// Название устройства пользователя.
+	DeviceName string
+
+	// Значение заголовка User-Agent запроса пользователя.
+	UserAgent string
+
+	// Способы аутентификации, использованные пользователем (RFC 8176).
+	Amr []string
+
+	// Области доступа, разделенные пробелами, которые требуется указать в ACCESS токене.
+	Scope string
+
+	// Идентификатор клиента OAuth 2.0, по запросу которого начинается сеанс.
+	ClientId string
+}
+
+// Обработчик команды на начало нового сеанса пользователя, прошедшего аутентификацию.
+//
+// Общий завершающий шаг для всех способов аутентификации.
+type SessionCreationCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *SessionCreationCommand
+
+	_tokensCreationHandler *TokensCreationCommandHandler
+	_createdPairOfTokens   *TokensCreationResult
+}
+
+// Обработать команду на начало нового сеанса пользователя, прошедшего аутентификацию.
+func (s *SessionCreationCommandHandler) Handle() *LoginResult {
+	s.deleteExpiredAuths()
+
+	s.evictOldestSessions()
+
+	return s.result()
+}
+
+// 1-й уровень абстракции.
+
+func (s *SessionCreationCommandHandler) deleteExpiredAuths() {
+	revokeDeletedAuths(services.AuthsRepository().DeleteExpiredByUser(s.Command.UserId))
+}
+
+func (s *SessionCreationCommandHandler) evictOldestSessions() {
+	for _, session := range s.sessionsToEvict() {
+		revokeDeletedAuths(services.AuthsRepository().DeleteByFamily(session.FamilyId))
+	}
+}
+
+func (s *SessionCreationCommandHandler) result() *LoginResult {
+	return &LoginResult{
+		AccessToken:  s.createdPairOfTokens().AccessToken,
+		RefreshToken: s.createdPairOfTokens().RefreshToken,
+	}
+}
+
+// 2-й уровень абстракции.
+
+func (s *SessionCreationCommandHandler) sessionsToEvict() []auths.Auth {
+	if services.MAX_SESSIONS_PER_USER <= 0 {
+		return nil
+	}
+
+	sessions, err := services.AuthsRepository().ListActiveByUser(s.Command.UserId)
+	if err != nil {
+		panic(err)
+	}
+
+	excess := len(sessions) - services.MAX_SESSIONS_PER_USER + 1
+	if excess <= 0 {
+		return nil
+	}
+
+	return sessions[:excess]
+}
+
+func (s *SessionCreationCommandHandler) createdPairOfTokens() *TokensCreationResult {
+	if s._createdPairOfTokens == nil {
+		s._createdPairOfTokens = s.createPairOfTokens()
+	}
+
+	return s._createdPairOfTokens
+}
+
+// 3-й уровень абстракции.
+
+func (s *SessionCreationCommandHandler) createPairOfTokens() *TokensCreationResult {
+	return s.tokenCreationHandler().Handle()
+}
+
+// 4-й уровень абстракции.
+
+func (s *SessionCreationCommandHandler) tokenCreationHandler() *TokensCreationCommandHandler {
+	if s._tokensCreationHandler == nil {
+		s._tokensCreationHandler = s.createTokenCreationHandler()
+	}
+
+	return s._tokensCreationHandler
+}
+
+// 5-й уровень абстракции.
+
+func (s *SessionCreationCommandHandler) createTokenCreationHandler() *TokensCreationCommandHandler {
+	tokenCreationHandler := TokensCreationCommandHandler{
+		Command: &TokensCreationCommand{
+			UserId:     s.Command.UserId,
+			UserIp:     s.Command.UserIp,
+			DeviceName: s.Command.DeviceName,
+			UserAgent:  s.Command.UserAgent,
+			Amr:        s.Command.Amr,
+			Scope:      s.Command.Scope,
+			ClientId:   s.Command.ClientId,
+		},
+	}
+
+	return &tokenCreationHandler
+}
+
+func createSession(command *SessionCreationCommand) *LoginResult {
+	handler := SessionCreationCommandHandler{
+		Command: command,
+	}
+
+	return handler.Handle()
+}
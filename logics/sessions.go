@@ -0,0 +1,161 @@
+package logics
+
+import (
+	"fmt"
+	"goauth/data/auths"
+	"goauth/logics/services"
+	"time"
+)
+
+// Сведения о сеансе пользователя.
+type Session struct {
+	// Идентификатор сеанса.
+	Id int32
+
+	// Название устройства, указанное пользователем при аутентификации.
+	DeviceName string
+
+	// Значение заголовка User-Agent последнего запроса сеанса на выдачу токенов.
+	UserAgent string
+
+	// IP адрес последнего запроса сеанса на выдачу токенов.
+	UserIp string
+
+	// Момент времени начала сеанса.
+	CreatedAt time.Time
+
+	// Момент времени последнего использования сеанса.
+	LastUsedAt time.Time
+
+	// Признак сеанса, в рамках которого выполнен запрос.
+	Current bool
+}
+
+// Запрос на получение активных сеансов пользователя.
+type SessionsQuery struct {
+	// ACCESS токен пользователя.
+	AccessToken string
+}
+
+// Обработчик запроса на получение активных сеансов пользователя.
+type SessionsQueryHandler struct {
+	// Обрабатываемый запрос.
+	Query *SessionsQuery
+
+	_verification *AccessTokenVerificationResult
+}
+
+// Обработать запрос на получение активных сеансов пользователя.
+func (s *SessionsQueryHandler) Handle() []Session {
+	result := []Session{}
+
+	for _, auth := range s.activeAuths() {
+		result = append(result, s.session(auth))
+	}
+
+	return result
+}
+
+// 1-й уровень абстракции.
+
+func (s *SessionsQueryHandler) activeAuths() []auths.Auth {
+	activeAuths, err := services.AuthsRepository().ListActiveByUser(s.verification().Auth.UserId)
+	if err != nil {
+		panic(err)
+	}
+
+	return activeAuths
+}
+
+func (s *SessionsQueryHandler) session(auth auths.Auth) Session {
+	return Session{
+		Id:         auth.FamilyId,
+		DeviceName: auth.DeviceName,
+		UserAgent:  auth.UserAgent,
+		UserIp:     auth.UserIp,
+		CreatedAt:  auth.CreatedAt,
+		LastUsedAt: auth.LastUsedAt,
+		Current:    auth.FamilyId == s.verification().Auth.FamilyId,
+	}
+}
+
+// 2-й уровень абстракции.
+
+func (s *SessionsQueryHandler) verification() *AccessTokenVerificationResult {
+	if s._verification == nil {
+		s._verification = verifyAccessToken(s.Query.AccessToken)
+	}
+
+	return s._verification
+}
+
+// Команда на завершение сеанса пользователя.
+type SessionRevocationCommand struct {
+	// ACCESS токен пользователя.
+	AccessToken string
+
+	// Идентификатор завершаемого сеанса.
+	SessionId int32
+}
+
+// Обработчик команды на завершение сеанса пользователя.
+type SessionRevocationCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *SessionRevocationCommand
+}
+
+// Обработать команду на завершение сеанса пользователя.
+func (s *SessionRevocationCommandHandler) Handle() {
+	deletedAuths := revokeDeletedAuths(services.AuthsRepository().DeleteSession(s.userId(), s.Command.SessionId))
+	if len(deletedAuths) == 0 {
+		panic(fmt.Errorf("%w: the session %d is not found", ErrNotFound, s.Command.SessionId))
+	}
+}
+
+// 1-й уровень абстракции.
+
+func (s *SessionRevocationCommandHandler) userId() int32 {
+	return verifyAccessToken(s.Command.AccessToken).Auth.UserId
+}
+
+// Команда на завершение всех сеансов пользователя, кроме текущего.
+type OtherSessionsRevocationCommand struct {
+	// ACCESS токен пользователя.
+	AccessToken string
+}
+
+// Обработчик команды на завершение всех сеансов пользователя, кроме текущего.
+type OtherSessionsRevocationCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *OtherSessionsRevocationCommand
+}
+
+// Обработать команду на завершение всех сеансов пользователя, кроме текущего.
+func (s *OtherSessionsRevocationCommandHandler) Handle() {
+	currentAuth := verifyAccessToken(s.Command.AccessToken).Auth
+
+	revokeDeletedAuths(services.AuthsRepository().DeleteOtherSessions(currentAuth.UserId, currentAuth.FamilyId))
+}
+
+// Проверить ACCESS токен, выданный пользователю сервисом напрямую. Токены клиентов OAuth 2.0 не принимаются.
+func verifyAccessToken(accessToken string) *AccessTokenVerificationResult {
+	handler := AccessTokenVerificationCommandHandler{
+		Command: &AccessTokenVerificationCommand{
+			AccessToken: accessToken,
+		},
+	}
+
+	return handler.Handle()
+}
+
+// Проверить ACCESS токен пользователя, выданный как сервисом напрямую, так и клиенту OAuth 2.0.
+func verifyAccessTokenOfAnyClient(accessToken string) *AccessTokenVerificationResult {
+	handler := AccessTokenVerificationCommandHandler{
+		Command: &AccessTokenVerificationCommand{
+			AccessToken:  accessToken,
+			AllowClients: true,
+		},
+	}
+
+	return handler.Handle()
+}
@@ -0,0 +1,441 @@
+package logics
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"goauth/data/authorizationcodes"
+	"goauth/data/auths"
+	"goauth/data/clients"
+	"goauth/data/clienttokens"
+	"goauth/data/users"
+	"goauth/logics/services"
+	"goauth/tokens/access"
+	"goauth/tokens/idtoken"
+	"goauth/tokens/jwt"
+	"goauth/tokens/opaque"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Команда на выдачу токенов через конечную точку токенов OAuth 2.0 (RFC 6749, раздел 3.2).
+type TokenCommand struct {
+	// Тип гранта, например, "authorization_code".
+	GrantType string
+
+	// Код авторизации.
+	Code string
+
+	// Адрес перенаправления, указанный в запросе на авторизацию.
+	RedirectUri string
+
+	// Идентификатор клиента.
+	ClientId string
+
+	// Секрет конфиденциального клиента.
+	ClientSecret string
+
+	// REFRESH токен, выданный клиенту. Только для гранта refresh_token.
+	RefreshToken string
+
+	// Запрошенные области доступа, разделенные пробелами. Только для грантов client_credentials и refresh_token.
+	Scope string
+
+	// Секрет PKCE (RFC 7636), SHA-256 от которого был передан в запросе на авторизацию.
+	CodeVerifier string
+
+	// IP адрес пользователя.
+	UserIp string
+
+	// Значение заголовка User-Agent запроса.
+	UserAgent string
+}
+
+// Ответ конечной точки токенов OAuth 2.0 (RFC 6749, раздел 5.1).
+type TokenResult struct {
+	// ACCESS токен.
+	AccessToken string `json:"access_token"`
+
+	// Тип токена, всегда "Bearer".
+	TokenType string `json:"token_type"`
+
+	// Время жизни ACCESS токена в секундах.
+	ExpiresIn int64 `json:"expires_in"`
+
+	// REFRESH токен.
+	RefreshToken string `json:"refresh_token,omitempty"`
+
+	// Области доступа ACCESS токена, разделенные пробелами.
+	Scope string `json:"scope,omitempty"`
+
+	// ID токен OpenID Connect. Выдается только для области доступа openid.
+	IdToken string `json:"id_token,omitempty"`
+}
+
+// Допустимый секрет PKCE (RFC 7636, раздел 4.1).
+var codeVerifierPattern = regexp.MustCompile(`^[A-Za-z0-9._~-]{43,128}$`)
+
+// Обработчик команды на выдачу токенов через конечную точку токенов OAuth 2.0.
+//
+// Клиенту, действующему от своего имени по гранту client_credentials, выдается только ACCESS токен с субъектом, равным идентификатору клиента.
+// По коду авторизации, выданному для области доступа openid, дополнительно выдается ID токен.
+// REFRESH токен выдается только клиентам, которым разрешен грант refresh_token.
+type TokenCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *TokenCommand
+
+	_client *clients.Client
+	_code   *authorizationcodes.AuthorizationCode
+	_user   *users.User
+
+	_refreshTokenVerification *RefreshTokenVerificationResult
+}
+
+// Обработать команду на выдачу токенов.
+func (s *TokenCommandHandler) Handle() *TokenResult {
+	if !slices.Contains(supportedGrants, s.Command.GrantType) {
+		panic(&OAuthError{Code: "unsupported_grant_type", Description: "the grant type is not supported", Err: ErrInvalidRequest})
+	}
+
+	s.panicIfGrantIsNotAllowed()
+
+	switch s.Command.GrantType {
+	case CLIENT_CREDENTIALS_GRANT:
+		return s.clientCredentialsGrant()
+	case REFRESH_TOKEN_GRANT:
+		return s.refreshTokenGrant()
+	}
+
+	return s.authorizationCodeGrant()
+}
+
+// 1-й уровень абстракции.
+
+func (s *TokenCommandHandler) panicIfGrantIsNotAllowed() {
+	if !slices.Contains(s.client().Grants, s.Command.GrantType) {
+		panic(&OAuthError{Code: "unauthorized_client", Description: "the client is not allowed to use the grant type", Err: ErrInvalidRequest})
+	}
+}
+
+func (s *TokenCommandHandler) clientCredentialsGrant() *TokenResult {
+	scope := strings.Join(s.client().Scopes, " ")
+	if s.Command.Scope != "" {
+		scope = s.allowedScope()
+	}
+
+	issuer := services.AccessTokenIssuer()
+
+	savedToken, err := services.ClientTokensRepository().Create(clienttokens.ClientToken{
+		ClientId:  s.client().Id,
+		ExpiresAt: time.Now().Add(issuer.Lifetime),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	token := access.NewForClient(issuer, s.client().Id, savedToken.Id)
+	token.Payload.Scope = scope
+
+	encodedToken, err := issuer.Encode(token)
+	if err != nil {
+		panic(err)
+	}
+
+	return &TokenResult{
+		AccessToken: encodedToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(issuer.Lifetime.Seconds()),
+		Scope:       scope,
+	}
+}
+
+func (s *TokenCommandHandler) authorizationCodeGrant() *TokenResult {
+	s.validateCodeBySavedHash()
+	s.validateCodeVerifier()
+	s.consumeCode()
+
+	createdPairOfTokens := createSession(&SessionCreationCommand{
+		UserId:     s.code().UserId,
+		UserIp:     s.Command.UserIp,
+		DeviceName: s.code().ClientId,
+		UserAgent:  s.Command.UserAgent,
+		Amr:        s.code().Amr,
+		Scope:      s.code().Scope,
+		ClientId:   s.code().ClientId,
+	})
+
+	result := &TokenResult{
+		AccessToken: createdPairOfTokens.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(services.AccessTokenIssuer().Lifetime.Seconds()),
+		Scope:       s.code().Scope,
+	}
+
+	if slices.Contains(s.client().Grants, REFRESH_TOKEN_GRANT) {
+		result.RefreshToken = createdPairOfTokens.RefreshToken
+	}
+
+	if scopeContains(s.code().Scope, OPENID_SCOPE) {
+		result.IdToken = s.createIdToken(createdPairOfTokens.AccessToken)
+	}
+
+	return result
+}
+
+func (s *TokenCommandHandler) refreshTokenGrant() *TokenResult {
+	s.validateRefreshToken()
+	s.consumeRefreshToken()
+	s.revokePreviousAccessToken()
+
+	scope := s.refreshedScope()
+	createdPairOfTokens := s.createRefreshedPairOfTokens(scope)
+
+	return &TokenResult{
+		AccessToken:  createdPairOfTokens.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(services.AccessTokenIssuer().Lifetime.Seconds()),
+		RefreshToken: createdPairOfTokens.RefreshToken,
+		Scope:        scope,
+	}
+}
+
+// 2-й уровень абстракции.
+
+func (s *TokenCommandHandler) client() *clients.Client {
+	if s._client == nil {
+		s._client = authenticateClient(s.Command.ClientId, s.Command.ClientSecret)
+	}
+
+	return s._client
+}
+
+func (s *TokenCommandHandler) allowedScope() string {
+	scope, ok := allowedScope(s.client(), s.Command.Scope)
+	if !ok {
+		panic(&OAuthError{Code: "invalid_scope", Description: "the requested scope is not allowed for the client", Err: ErrInvalidRequest})
+	}
+
+	return scope
+}
+
+func (s *TokenCommandHandler) validateCodeBySavedHash() {
+	err := services.OneTimeTokenDigester().Verify(s.Command.Code, s.code().CodeHash)
+	if err != nil {
+		panicBecauseOfInvalidGrant()
+	}
+
+	if s.code().ClientId != s.Command.ClientId || s.code().RedirectUri != s.Command.RedirectUri {
+		panicBecauseOfInvalidGrant()
+	}
+}
+
+func (s *TokenCommandHandler) validateCodeVerifier() {
+	if !codeVerifierPattern.MatchString(s.Command.CodeVerifier) {
+		panicBecauseOfInvalidGrant()
+	}
+
+	if subtle.ConstantTimeCompare([]byte(codeChallenge(s.Command.CodeVerifier)), []byte(s.code().CodeChallenge)) != 1 {
+		panicBecauseOfInvalidGrant()
+	}
+}
+
+func (s *TokenCommandHandler) consumeCode() {
+	consumed, err := services.AuthorizationCodesRepository().Consume(s.code().Id)
+	if err != nil {
+		panic(err)
+	}
+
+	if !consumed {
+		panicBecauseOfInvalidGrant()
+	}
+}
+
+func (s *TokenCommandHandler) validateRefreshToken() {
+	err := catchUnauthorized(func() { s.refreshTokenVerification() })
+	if err != nil {
+		panicBecauseOfInvalidRefreshToken()
+	}
+
+	auth := s.refreshTokenVerification().Auth
+	if auth.ClientId != s.client().Id {
+		panicBecauseOfInvalidRefreshToken()
+	}
+
+	if refreshTokenIsReused(auth) {
+		revokeAuthFamily(auth, s.Command.UserIp)
+		panicBecauseOfInvalidRefreshToken()
+	}
+}
+
+func (s *TokenCommandHandler) consumeRefreshToken() {
+	consumed, err := services.AuthsRepository().Consume(s.refreshTokenVerification().Auth.Id)
+	if err != nil {
+		panic(err)
+	}
+
+	if !consumed {
+		revokeAuthFamily(s.refreshTokenVerification().Auth, s.Command.UserIp)
+		panicBecauseOfInvalidRefreshToken()
+	}
+}
+
+func (s *TokenCommandHandler) revokePreviousAccessToken() {
+	handler := AccessTokenRevocationCommandHandler{
+		Command: &AccessTokenRevocationCommand{
+			Auths: []auths.Auth{*s.refreshTokenVerification().Auth},
+		},
+	}
+
+	handler.Handle()
+}
+
+// Области доступа нового ACCESS токена. Клиент может сузить области, разрешенные пользователем, но не расширить их (RFC 6749, раздел 6).
+func (s *TokenCommandHandler) refreshedScope() string {
+	grantedScope := s.refreshTokenVerification().Auth.Scope
+	if s.Command.Scope == "" {
+		return grantedScope
+	}
+
+	requested := strings.Fields(s.Command.Scope)
+	for _, scope := range requested {
+		if !scopeContains(grantedScope, scope) {
+			panic(&OAuthError{Code: "invalid_scope", Description: "the requested scope exceeds the scope granted by the user", Err: ErrInvalidRequest})
+		}
+	}
+
+	return strings.Join(requested, " ")
+}
+
+func (s *TokenCommandHandler) createRefreshedPairOfTokens(scope string) *TokensCreationResult {
+	previousAuth := s.refreshTokenVerification().Auth
+
+	handler := TokensCreationCommandHandler{
+		Command: &TokensCreationCommand{
+			UserId:           previousAuth.UserId,
+			UserIp:           s.Command.UserIp,
+			Scope:            scope,
+			ClientId:         previousAuth.ClientId,
+			ParentAuthId:     previousAuth.Id,
+			AuthFamilyId:     previousAuth.FamilyId,
+			DeviceName:       previousAuth.DeviceName,
+			UserAgent:        s.Command.UserAgent,
+			SessionCreatedAt: previousAuth.CreatedAt,
+			Amr:              previousAuth.Amr,
+		},
+	}
+
+	return handler.Handle()
+}
+
+func (s *TokenCommandHandler) createIdToken(accessToken string) string {
+	issuer := services.IdTokenIssuer()
+
+	key, err := issuer.Keys.Current()
+	if err != nil {
+		panic(err)
+	}
+
+	accessTokenHash, err := jwt.HalfHash(key.Algorithm, accessToken)
+	if err != nil {
+		panic(err)
+	}
+
+	userInfo := userInfo(s.user(), s.code().Scope)
+
+	token := idtoken.New(issuer, s.code().UserId, s.code().ClientId)
+	token.Payload.AuthTime = s.code().AuthTime.Unix()
+	token.Payload.Nonce = s.code().Nonce
+	token.Payload.AccessTokenHash = accessTokenHash
+	token.Payload.Amr = s.code().Amr
+	token.Payload.Email = userInfo.Email
+	token.Payload.EmailVerified = userInfo.EmailVerified
+
+	encodedToken, err := issuer.Encode(token)
+	if err != nil {
+		panic(err)
+	}
+
+	return encodedToken
+}
+
+// 3-й уровень абстракции.
+
+func (s *TokenCommandHandler) code() *authorizationcodes.AuthorizationCode {
+	if s._code == nil {
+		s._code = s.getCode()
+	}
+
+	return s._code
+}
+
+func (s *TokenCommandHandler) refreshTokenVerification() *RefreshTokenVerificationResult {
+	if s._refreshTokenVerification == nil {
+		s._refreshTokenVerification = verifyRefreshToken(s.Command.RefreshToken)
+	}
+
+	return s._refreshTokenVerification
+}
+
+func (s *TokenCommandHandler) user() *users.User {
+	if s._user == nil {
+		s._user = s.getUser()
+	}
+
+	return s._user
+}
+
+// 4-й уровень абстракции.
+
+func (s *TokenCommandHandler) getUser() *users.User {
+	user, err := services.UsersRepository().Get(s.code().UserId)
+	if err != nil {
+		panic(err)
+	}
+
+	return user
+}
+
+func (s *TokenCommandHandler) getCode() *authorizationcodes.AuthorizationCode {
+	id, err := opaque.Id(s.Command.Code)
+	if err != nil {
+		panicBecauseOfInvalidGrant()
+	}
+
+	code, err := services.AuthorizationCodesRepository().Get(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		panicBecauseOfInvalidGrant()
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	return code
+}
+
+// Вычислить вызов PKCE по способу S256: SHA-256 от секрета в кодировке Base64URL без выравнивания (RFC 7636, раздел 4.2).
+func codeChallenge(codeVerifier string) string {
+	hash := sha256.Sum256([]byte(codeVerifier))
+
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+func panicBecauseOfInvalidGrant() {
+	panic(&OAuthError{
+		Code:        "invalid_grant",
+		Description: "the authorization code is invalid, expired, has already been used or was issued for another client, redirect URI or code verifier",
+		Err:         ErrInvalidRequest,
+	})
+}
+
+func panicBecauseOfInvalidRefreshToken() {
+	panic(&OAuthError{
+		Code:        "invalid_grant",
+		Description: "the refresh token is invalid, expired, revoked, has already been used or was issued for another client",
+		Err:         ErrInvalidRequest,
+	})
+}
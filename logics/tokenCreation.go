@@ -2,12 +2,14 @@ package logics
 
 import (
 	"goauth/data/auths"
+	"goauth/data/users"
 	"goauth/logics/services"
 	"goauth/tokens/access"
 	"goauth/tokens/jwt"
+	"goauth/tokens/opaque"
 	"goauth/tokens/refresh"
-
-	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"time"
 )
 
 // Команда для создания пары токенов.
@@ -17,6 +19,32 @@ type TokensCreationCommand struct {
 
 	// IP адрес пользователя.
 	UserIp string
+
+	// Области доступа, разделенные пробелами, разрешенные пользователем клиенту OAuth 2.0.
+	//
+	// Учитываются только вместе с ClientId. Токенам, выданным сервисом напрямую, области доступа назначаются из записи пользователя.
+	Scope string
+
+	// Идентификатор клиента OAuth 2.0, по запросу которого выдается пара токенов. Пустая строка при аутентификации в сервисе напрямую.
+	ClientId string
+
+	// Идентификатор записи в таблице AUTHS, REFRESH токен которой обменивается на новую пару токенов. 0 при аутентификации.
+	ParentAuthId int32
+
+	// Идентификатор семейства записей в таблице AUTHS, к которому относится новая пара токенов. 0 при аутентификации.
+	AuthFamilyId int32
+
+	// Название устройства пользователя.
+	DeviceName string
+
+	// Значение заголовка User-Agent запроса пользователя.
+	UserAgent string
+
+	// Момент времени начала сеанса. Нулевое значение при аутентификации.
+	SessionCreatedAt time.Time
+
+	// Способы аутентификации, использованные при начале сеанса (RFC 8176).
+	Amr []string
 }
 
 // Результат создания пары токенов.
@@ -40,6 +68,8 @@ type TokensCreationCommandHandler struct {
 
 	_refreshToken        *jwt.Jwt[refresh.RefreshTokenPayload]
 	_encodedRefreshToken *string
+
+	_user *users.User
 }
 
 // Обработать команду для создания пары токенов.
@@ -53,9 +83,12 @@ func (s *TokensCreationCommandHandler) Handle() *TokensCreationResult {
 
 func (s *TokensCreationCommandHandler) saveRefreshTokenHash() {
 	createdAuth := *s.createdAuth()
-	createdAuth.RefreshTokenHash = string(s.createRefreshTokenHash())
+	createdAuth.RefreshTokenHash = s.createRefreshTokenHash()
 
-	services.AuthsRepository().Update(createdAuth)
+	err := services.AuthsRepository().Update(createdAuth)
+	if err != nil {
+		panic(err)
+	}
 }
 
 func (s *TokensCreationCommandHandler) result() *TokensCreationResult {
@@ -75,13 +108,8 @@ func (s *TokensCreationCommandHandler) createdAuth() *auths.Auth {
 	return s._createdAuth
 }
 
-func (s *TokensCreationCommandHandler) createRefreshTokenHash() []byte {
-	refreshTokenHash, err := bcrypt.GenerateFromPassword(s.encodedRefreshTokenBytesForBcrypt(), bcrypt.DefaultCost)
-	if err != nil {
-		panic(err)
-	}
-
-	return refreshTokenHash
+func (s *TokensCreationCommandHandler) createRefreshTokenHash() string {
+	return services.RefreshTokenDigester().Digest(*s.encodedRefreshToken())
 }
 
 func (s *TokensCreationCommandHandler) encodedAccessToken() *string {
@@ -95,8 +123,21 @@ func (s *TokensCreationCommandHandler) encodedAccessToken() *string {
 // 3-й уровень абстракции.
 
 func (s *TokensCreationCommandHandler) createAuth() *auths.Auth {
+	now := time.Now()
+
 	token, err := services.AuthsRepository().Create(auths.Auth{
-		UserId: s.Command.UserId,
+		UserId:     s.Command.UserId,
+		UserIp:     s.Command.UserIp,
+		ExpiresAt:  now.Add(services.RefreshTokenIssuer().Lifetime),
+		ParentId:   s.Command.ParentAuthId,
+		FamilyId:   s.Command.AuthFamilyId,
+		DeviceName: s.Command.DeviceName,
+		UserAgent:  s.Command.UserAgent,
+		CreatedAt:  s.sessionCreatedAt(now),
+		LastUsedAt: now,
+		Amr:        s.Command.Amr,
+		ClientId:   s.Command.ClientId,
+		Scope:      s.scope(),
 	})
 	if err != nil {
 		panic(err)
@@ -105,8 +146,12 @@ func (s *TokensCreationCommandHandler) createAuth() *auths.Auth {
 	return token
 }
 
-func (s *TokensCreationCommandHandler) encodedRefreshTokenBytesForBcrypt() []byte {
-	return []byte(*s.encodedRefreshToken())[:MAX_BYTES_IN_VALUE_FOR_BCRYPT]
+func (s *TokensCreationCommandHandler) sessionCreatedAt(now time.Time) time.Time {
+	if s.Command.SessionCreatedAt.IsZero() {
+		return now
+	}
+
+	return s.Command.SessionCreatedAt
 }
 
 func (s *TokensCreationCommandHandler) encodeAccessToken() *string {
@@ -139,6 +184,10 @@ func (s *TokensCreationCommandHandler) accessToken() *jwt.Jwt[access.AccessToken
 // 5-й уровень абстракции.
 
 func (s *TokensCreationCommandHandler) encodeRefreshToken() *string {
+	if services.REFRESH_TOKENS_ARE_OPAQUE {
+		return s.createOpaqueRefreshToken()
+	}
+
 	encodedRefreshToken, err := services.RefreshTokenIssuer().Encode(*s.refreshToken())
 	if err != nil {
 		panic(err)
@@ -148,7 +197,13 @@ func (s *TokensCreationCommandHandler) encodeRefreshToken() *string {
 }
 
 func (s *TokensCreationCommandHandler) createAccessToken() *jwt.Jwt[access.AccessTokenPayload] {
-	token := services.AccessTokenIssuer().New(s.Command.UserId, s.createdAuth().Id)
+	token := access.New(services.AccessTokenIssuer(), s.Command.UserId, s.createdAuth().Id)
+	token.Payload.Scope = s.scope()
+	token.Payload.ClientId = s.Command.ClientId
+	token.Payload.Roles = s.user().Roles
+	token.Payload.Amr = s.Command.Amr
+
+	s.enrichAccessTokenClaims(&token.Payload)
 
 	return &token
 }
@@ -163,10 +218,53 @@ func (s *TokensCreationCommandHandler) refreshToken() *jwt.Jwt[refresh.RefreshTo
 	return s._refreshToken
 }
 
+func (s *TokensCreationCommandHandler) user() *users.User {
+	if s._user == nil {
+		s._user = s.getUser()
+	}
+
+	return s._user
+}
+
+func (s *TokensCreationCommandHandler) scope() string {
+	if s.Command.ClientId != "" {
+		return s.Command.Scope
+	}
+
+	return strings.Join(s.user().Scopes, " ")
+}
+
+func (s *TokensCreationCommandHandler) enrichAccessTokenClaims(payload *access.AccessTokenPayload) {
+	for _, enricher := range services.AccessTokenClaimsEnrichers {
+		err := enricher(s.user(), payload)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
 // 7-й уровень абстракции.
 
 func (s *TokensCreationCommandHandler) createRefreshToken() *jwt.Jwt[refresh.RefreshTokenPayload] {
-	token := services.RefreshTokenIssuer().New(s.Command.UserIp, s.createdAuth().Id)
+	token := refresh.New(services.RefreshTokenIssuer(), s.Command.UserIp, s.createdAuth().Id)
 
 	return &token
 }
+
+func (s *TokensCreationCommandHandler) createOpaqueRefreshToken() *string {
+	token, err := opaque.New(s.createdAuth().Id)
+	if err != nil {
+		panic(err)
+	}
+
+	return &token
+}
+
+func (s *TokensCreationCommandHandler) getUser() *users.User {
+	user, err := services.UsersRepository().Get(s.Command.UserId)
+	if err != nil {
+		panic(err)
+	}
+
+	return user
+}
@@ -0,0 +1,130 @@
+package logics
+
+import (
+	"fmt"
+	"goauth/data/auths"
+	"goauth/logics/services"
+	"goauth/tokens/access"
+	"goauth/tokens/jwt"
+)
+
+// Команда на проверку пары токенов, предъявленной пользователем.
+type TokenPairVerificationCommand struct {
+	// ACCESS токен пользователя.
+	AccessToken string
+
+	// REFRESH токен пользователя.
+	RefreshToken string
+
+	// IP адрес пользователя.
+	UserIp string
+}
+
+// Результат проверки пары токенов.
+type TokenPairVerificationResult struct {
+	// Декодированный ACCESS токен. Срок его действия не проверяется.
+	AccessToken *jwt.Jwt[access.AccessTokenPayload]
+
+	// Результат проверки REFRESH токена.
+	*RefreshTokenVerificationResult
+}
+
+// Обработчик команды на проверку пары токенов, предъявленной пользователем.
+//
+// Если REFRESH токен уже был использован, сеанс, к которому он относится, завершается.
+type TokenPairVerificationCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *TokenPairVerificationCommand
+
+	_refreshTokenVerification *RefreshTokenVerificationResult
+
+	_accessToken *jwt.Jwt[access.AccessTokenPayload]
+}
+
+// Обработать команду на проверку пары токенов, предъявленной пользователем.
+func (s *TokenPairVerificationCommandHandler) Handle() *TokenPairVerificationResult {
+	s.panicIfTokensHaveDifferentIds()
+	s.panicIfRefreshTokenIsReused()
+
+	return s.result()
+}
+
+// 1-й уровень абстракции.
+
+func (s *TokenPairVerificationCommandHandler) panicIfTokensHaveDifferentIds() {
+	if s.accessToken().Payload.Id != s.refreshTokenVerification().Auth.Id {
+		panic(fmt.Errorf("%w: tokens have different ids", ErrUnauthorized))
+	}
+}
+
+func (s *TokenPairVerificationCommandHandler) panicIfRefreshTokenIsReused() {
+	if refreshTokenIsReused(s.refreshTokenVerification().Auth) {
+		revokeAuthFamily(s.refreshTokenVerification().Auth, s.Command.UserIp)
+		panic(fmt.Errorf("%w: REFRESH token has already been used", ErrUnauthorized))
+	}
+}
+
+func (s *TokenPairVerificationCommandHandler) result() *TokenPairVerificationResult {
+	return &TokenPairVerificationResult{
+		AccessToken:                    s.accessToken(),
+		RefreshTokenVerificationResult: s.refreshTokenVerification(),
+	}
+}
+
+// 2-й уровень абстракции.
+
+func (s *TokenPairVerificationCommandHandler) accessToken() *jwt.Jwt[access.AccessTokenPayload] {
+	if s._accessToken == nil {
+		s._accessToken = s.decodeAccessToken()
+	}
+
+	return s._accessToken
+}
+
+func (s *TokenPairVerificationCommandHandler) refreshTokenVerification() *RefreshTokenVerificationResult {
+	if s._refreshTokenVerification == nil {
+		s._refreshTokenVerification = verifyRefreshToken(s.Command.RefreshToken)
+	}
+
+	return s._refreshTokenVerification
+}
+
+// 3-й уровень абстракции.
+
+func (s *TokenPairVerificationCommandHandler) decodeAccessToken() *jwt.Jwt[access.AccessTokenPayload] {
+	decodedAccessToken, err := services.AccessTokenIssuer().DecodeExpired(s.Command.AccessToken)
+	if err != nil {
+		panic(fmt.Errorf("%w: %w", ErrUnauthorized, err))
+	}
+
+	return decodedAccessToken
+}
+
+func verifyTokenPair(accessToken string, refreshToken string, userIp string) *TokenPairVerificationResult {
+	handler := TokenPairVerificationCommandHandler{
+		Command: &TokenPairVerificationCommand{
+			AccessToken:  accessToken,
+			RefreshToken: refreshToken,
+			UserIp:       userIp,
+		},
+	}
+
+	return handler.Handle()
+}
+
+// Определить, предъявлен ли REFRESH токен повторно: запись таблицы AUTHS уже была обменяна на новую пару токенов.
+// Повторное предъявление означает, что токен, скорее всего, украден, и требует завершить все семейство записей.
+func refreshTokenIsReused(auth *auths.Auth) bool {
+	return auth.ConsumedAt != nil
+}
+
+func revokeAuthFamily(auth *auths.Auth, userIp string) {
+	handler := AuthFamilyRevocationCommandHandler{
+		Command: &AuthFamilyRevocationCommand{
+			Auth:   auth,
+			UserIp: userIp,
+		},
+	}
+
+	handler.Handle()
+}
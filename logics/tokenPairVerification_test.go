@@ -0,0 +1,57 @@
+package logics
+
+import (
+	"goauth/data/auths"
+	"goauth/logics/services"
+	"testing"
+	"time"
+)
+
+func TestRefreshTokenIsReused(t *testing.T) {
+	consumedAt := time.Now().Add(-time.Minute)
+
+	if refreshTokenIsReused(&auths.Auth{Id: 1}) {
+		t.Error("refreshTokenIsReused() reported an unused REFRESH token as reused")
+	}
+
+	if !refreshTokenIsReused(&auths.Auth{Id: 1, ConsumedAt: &consumedAt}) {
+		t.Error("refreshTokenIsReused() accepted a consumed REFRESH token")
+	}
+}
+
+func TestRevokeDeletedAuthsRevokesWholeFamily(t *testing.T) {
+	now := time.Now()
+	consumedAt := now.Add(-time.Minute)
+
+	// Семейство после двух обновлений: две использованные записи и текущая.
+	family := []auths.Auth{
+		{Id: 1_000_001, FamilyId: 1_000_001, ConsumedAt: &consumedAt, LastUsedAt: now.Add(-2 * time.Minute)},
+		{Id: 1_000_002, FamilyId: 1_000_001, ParentId: 1_000_001, ConsumedAt: &consumedAt, LastUsedAt: now.Add(-time.Minute)},
+		{Id: 1_000_003, FamilyId: 1_000_001, ParentId: 1_000_002, LastUsedAt: now},
+	}
+
+	// Запись, ACCESS токен которой истек задолго до отзыва, в хранилище не попадает.
+	expired := auths.Auth{Id: 1_000_004, FamilyId: 1_000_001, LastUsedAt: now.Add(-24 * time.Hour)}
+
+	revokeDeletedAuths(append(family, expired), nil)
+
+	for _, auth := range family {
+		revoked, err := services.RevokedTokensStore().IsRevoked(auth.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !revoked {
+			t.Errorf("the ACCESS token of AUTHS record %d is not revoked", auth.Id)
+		}
+	}
+
+	revoked, err := services.RevokedTokensStore().IsRevoked(expired.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if revoked {
+		t.Error("the expired ACCESS token is stored in the revoked tokens store")
+	}
+}
@@ -0,0 +1,125 @@
+package logics
+
+import (
+	"goauth/data/clients"
+	"goauth/logics/services"
+	"goauth/tokens/access"
+	"goauth/tokens/jwt"
+	"time"
+)
+
+// Команда на отзыв токена (RFC 7009).
+type TokenRevocationCommand struct {
+	// ACCESS или REFRESH токен.
+	Token string
+
+	// Предполагаемый тип токена: "access_token", "refresh_token" или пустая строка.
+	TokenTypeHint string
+
+	// Идентификатор клиента, отзывающего токен.
+	ClientId string
+
+	// Секрет клиента, отзывающего токен. Пустая строка для публичных клиентов.
+	ClientSecret string
+}
+
+// Обработчик команды на отзыв токена.
+//
+// Клиент аутентифицируется так же, как при получении токенов, и может отозвать только токены, выданные ему самому (RFC 7009, раздел 2.1).
+// Отзыв REFRESH токена завершает сеанс, к которому он относится, вместе с его ACCESS токенами.
+// Отзыв ACCESS токена не затрагивает сеанс. Неизвестные, недействительные и выданные другим клиентам токены игнорируются.
+//
+// Токены, выданные сервисом напрямую (с пустым идентификатором клиента), не принадлежат ни одному клиенту и поэтому тоже игнорируются:
+// сеанс с такими токенами завершается командой на выход (LogoutCommand) или на завершение сеанса (SessionRevocationCommand).
+type TokenRevocationCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *TokenRevocationCommand
+
+	_client *clients.Client
+}
+
+// Обработать команду на отзыв токена.
+func (s *TokenRevocationCommandHandler) Handle() {
+	s.client()
+
+	for _, revoke := range s.revokers() {
+		if revoke() {
+			return
+		}
+	}
+}
+
+// 1-й уровень абстракции.
+
+func (s *TokenRevocationCommandHandler) client() *clients.Client {
+	if s._client == nil {
+		s._client = authenticateClient(s.Command.ClientId, s.Command.ClientSecret)
+	}
+
+	return s._client
+}
+
+// Получить функции отзыва токена в порядке, соответствующем предполагаемому типу токена.
+// Функция возвращает false, если токен не относится к ее типу.
+func (s *TokenRevocationCommandHandler) revokers() []func() bool {
+	if s.Command.TokenTypeHint == "access_token" {
+		return []func() bool{s.revokeAccessToken, s.revokeClientAccessToken, s.revokeRefreshToken}
+	}
+
+	return []func() bool{s.revokeRefreshToken, s.revokeAccessToken, s.revokeClientAccessToken}
+}
+
+// 2-й уровень абстракции.
+
+func (s *TokenRevocationCommandHandler) revokeAccessToken() bool {
+	var verification *AccessTokenVerificationResult
+
+	err := catchUnauthorized(func() {
+		verification = verifyAccessTokenOfAnyClient(s.Command.Token)
+	})
+	if err != nil || verification.Token.Payload.ClientId != s.client().Id {
+		return false
+	}
+
+	payload := verification.Token.Payload
+	expiresAt := time.Unix(payload.ExpirationTime, 0).Add(services.Validator().Skew)
+
+	err = services.RevokedTokensStore().Revoke(payload.Id, expiresAt)
+	if err != nil {
+		panic(err)
+	}
+
+	return true
+}
+
+// Отозвать ACCESS токен, выданный клиенту по гранту client_credentials: удалить запись в таблице CLIENT_TOKENS
+// и поместить токен в хранилище отозванных токенов.
+func (s *TokenRevocationCommandHandler) revokeClientAccessToken() bool {
+	var token *jwt.Jwt[access.AccessTokenPayload]
+
+	err := catchUnauthorized(func() {
+		token = verifyClientAccessToken(s.Command.Token)
+	})
+	if err != nil || token.Payload.ClientId != s.client().Id {
+		return false
+	}
+
+	revokeDeletedClientTokens(services.ClientTokensRepository().Delete(token.Payload.Id))
+
+	return true
+}
+
+func (s *TokenRevocationCommandHandler) revokeRefreshToken() bool {
+	var verification *RefreshTokenVerificationResult
+
+	err := catchUnauthorized(func() {
+		verification = verifyRefreshToken(s.Command.Token)
+	})
+	if err != nil || verification.Auth.ClientId != s.client().Id {
+		return false
+	}
+
+	revokeDeletedAuths(services.AuthsRepository().DeleteByFamily(verification.Auth.FamilyId))
+
+	return true
+}
@@ -0,0 +1,68 @@
+package logics
+
+import (
+	"goauth/data/auths"
+	"testing"
+)
+
+func TestCodeChallenge(t *testing.T) {
+	// Пример из RFC 7636, приложение B.
+	codeVerifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+	if !codeVerifierPattern.MatchString(codeVerifier) {
+		t.Fatal("codeVerifierPattern rejected the RFC 7636 code verifier")
+	}
+
+	challenge := codeChallenge(codeVerifier)
+	if challenge != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Fatalf("codeChallenge() = %s", challenge)
+	}
+
+	if !codeChallengePattern.MatchString(challenge) {
+		t.Fatal("codeChallengePattern rejected the RFC 7636 code challenge")
+	}
+}
+
+func TestRefreshedScope(t *testing.T) {
+	cases := []struct {
+		requested string
+		want      string
+		ok        bool
+	}{
+		{"", "openid email", true},
+		{"email", "email", true},
+		{" email  openid ", "email openid", true},
+		{"openid profile", "", false},
+		{"admin", "", false},
+	}
+
+	for _, c := range cases {
+		handler := TokenCommandHandler{
+			Command: &TokenCommand{Scope: c.requested},
+
+			_refreshTokenVerification: &RefreshTokenVerificationResult{Auth: &auths.Auth{Scope: "openid email"}},
+		}
+
+		var scope string
+		err := catchOAuthError(func() { scope = handler.refreshedScope() })
+
+		if (err == nil) != c.ok || scope != c.want {
+			t.Errorf("refreshedScope() with scope %q = %q, %v", c.requested, scope, err)
+		}
+	}
+}
+
+func catchOAuthError(action func()) (err *OAuthError) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+
+		err = recovered.(*OAuthError)
+	}()
+
+	action()
+
+	return nil
+}
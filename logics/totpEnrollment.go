@@ -0,0 +1,209 @@
+package logics
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"goauth/data/users"
+	"goauth/logics/services"
+	"goauth/secrets"
+	"goauth/totp"
+	"strings"
+	"time"
+)
+
+// Команда на начало подключения второго фактора TOTP.
+type TotpEnrollmentCommand struct {
+	// ACCESS токен пользователя.
+	AccessToken string
+}
+
+// Результат начала подключения второго фактора TOTP.
+type TotpEnrollmentResult struct {
+	// Секрет в кодировке Base32 для ручного ввода в приложение-аутентификатор.
+	Secret string
+
+	// URI `otpauth://` для добавления секрета в приложение-аутентификатор, обычно в виде QR-кода.
+	Uri string
+}
+
+// Обработчик команды на начало подключения второго фактора TOTP.
+//
+// Секрет сохраняется неподтвержденным и не требуется при аутентификации, пока не будет подтвержден первым кодом.
+// Подключение разрешено только в сеансе, начатом не раньше `services.MAX_SESSION_AGE_FOR_TOTP_ENROLLMENT` назад.
+type TotpEnrollmentCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *TotpEnrollmentCommand
+
+	_verification *AccessTokenVerificationResult
+	_user         *users.User
+}
+
+// Обработать команду на начало подключения второго фактора TOTP.
+func (s *TotpEnrollmentCommandHandler) Handle() *TotpEnrollmentResult {
+	s.panicIfSessionIsNotRecent()
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		panic(err)
+	}
+
+	updated, err := services.UsersRepository().UpdateTotpSecret(s.user().Id, secret)
+	if err != nil {
+		panic(err)
+	}
+
+	if !updated {
+		panic(fmt.Errorf("%w: TOTP is already enabled", ErrInvalidRequest))
+	}
+
+	return &TotpEnrollmentResult{
+		Secret: secret,
+		Uri:    totp.Uri(secrets.TOKEN_ISSUER_NAME, s.user().Email, secret),
+	}
+}
+
+// 1-й уровень абстракции.
+
+func (s *TotpEnrollmentCommandHandler) panicIfSessionIsNotRecent() {
+	if time.Since(s.verification().Auth.CreatedAt) > services.MAX_SESSION_AGE_FOR_TOTP_ENROLLMENT {
+		panic(fmt.Errorf("%w: TOTP enrollment requires a recent authentication, log in again", ErrUnauthorized))
+	}
+}
+
+func (s *TotpEnrollmentCommandHandler) user() *users.User {
+	if s._user == nil {
+		s._user = s.getUser()
+	}
+
+	return s._user
+}
+
+// 2-й уровень абстракции.
+
+func (s *TotpEnrollmentCommandHandler) verification() *AccessTokenVerificationResult {
+	if s._verification == nil {
+		s._verification = verifyAccessToken(s.Command.AccessToken)
+	}
+
+	return s._verification
+}
+
+func (s *TotpEnrollmentCommandHandler) getUser() *users.User {
+	user, err := services.UsersRepository().Get(s.verification().Auth.UserId)
+	if err != nil {
+		panic(err)
+	}
+
+	return user
+}
+
+// Команда на подтверждение подключения второго фактора TOTP первым кодом.
+type TotpConfirmationCommand struct {
+	// ACCESS токен пользователя.
+	AccessToken string
+
+	// Код из приложения-аутентификатора.
+	Code string
+}
+
+// Результат подтверждения подключения второго фактора TOTP.
+type TotpConfirmationResult struct {
+	// Одноразовые коды восстановления для входа без приложения-аутентификатора. Показываются только один раз.
+	RecoveryCodes []string
+}
+
+// Обработчик команды на подтверждение подключения второго фактора TOTP первым кодом.
+type TotpConfirmationCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *TotpConfirmationCommand
+
+	_user *users.User
+}
+
+// Обработать команду на подтверждение подключения второго фактора TOTP первым кодом.
+func (s *TotpConfirmationCommandHandler) Handle() *TotpConfirmationResult {
+	s.enableTotp()
+
+	return &TotpConfirmationResult{
+		RecoveryCodes: createRecoveryCodes(s.user().Id),
+	}
+}
+
+// 1-й уровень абстракции.
+
+func (s *TotpConfirmationCommandHandler) enableTotp() {
+	if s.user().TotpSecret == "" || s.user().TotpEnabled {
+		panic(fmt.Errorf("%w: TOTP enrollment has not been started", ErrInvalidRequest))
+	}
+
+	step, valid, err := totp.Verify(s.user().TotpSecret, s.Command.Code, time.Now())
+	if err != nil {
+		panic(err)
+	}
+
+	if !valid {
+		panic(fmt.Errorf("%w: the TOTP code is invalid", ErrInvalidRequest))
+	}
+
+	enabled, err := services.UsersRepository().EnableTotp(s.user().Id, step)
+	if err != nil {
+		panic(err)
+	}
+
+	if !enabled {
+		panic(fmt.Errorf("%w: TOTP is already enabled", ErrInvalidRequest))
+	}
+}
+
+// 2-й уровень абстракции.
+
+func (s *TotpConfirmationCommandHandler) user() *users.User {
+	if s._user == nil {
+		s._user = getVerifiedUser(s.Command.AccessToken)
+	}
+
+	return s._user
+}
+
+// Получить пользователя, которому выдан проверенный ACCESS токен.
+func getVerifiedUser(accessToken string) *users.User {
+	user, err := services.UsersRepository().Get(verifyAccessToken(accessToken).Auth.UserId)
+	if err != nil {
+		panic(err)
+	}
+
+	return user
+}
+
+// Создать новые коды восстановления пользователя, заменив прежние. В БД сохраняются только их дайджесты.
+func createRecoveryCodes(userId int32) []string {
+	codes := make([]string, RECOVERY_CODES_COUNT)
+	codeHashes := make([]string, RECOVERY_CODES_COUNT)
+
+	for i := range codes {
+		value := make([]byte, 5)
+		_, err := rand.Read(value)
+		if err != nil {
+			panic(err)
+		}
+
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(value))
+		codes[i] = code[:4] + "-" + code[4:]
+		codeHashes[i] = recoveryCodeHash(code)
+	}
+
+	err := services.RecoveryCodesRepository().Replace(userId, codeHashes)
+	if err != nil {
+		panic(err)
+	}
+
+	return codes
+}
+
+// Вычислить дайджест кода восстановления, не зависящий от регистра и разделителей.
+func recoveryCodeHash(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+
+	return services.OneTimeTokenDigester().Digest(normalized)
+}
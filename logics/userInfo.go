@@ -0,0 +1,104 @@
+package logics
+
+import (
+	"fmt"
+	"goauth/data/users"
+	"goauth/logics/services"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Утверждения о пользователе OpenID Connect (OpenID Connect Core 1.0, раздел 5.3.2).
+type UserInfo struct {
+	// Десятичный идентификатор пользователя.
+	Subject string `json:"sub"`
+
+	// Адрес электронной почты пользователя. Только для области доступа email.
+	Email string `json:"email,omitempty"`
+
+	// Признак подтвержденного адреса электронной почты. Только для области доступа email.
+	EmailVerified *bool `json:"email_verified,omitempty"`
+}
+
+// Запрос на получение утверждений о пользователе по ACCESS токену.
+type UserInfoQuery struct {
+	// ACCESS токен пользователя.
+	AccessToken string
+}
+
+// Обработчик запроса на получение утверждений о пользователе по ACCESS токену.
+//
+// Состав утверждений определяется областями доступа токена: без области openid запрос отклоняется.
+type UserInfoQueryHandler struct {
+	// Обрабатываемый запрос.
+	Query *UserInfoQuery
+
+	_verification *AccessTokenVerificationResult
+	_user         *users.User
+}
+
+// Обработать запрос на получение утверждений о пользователе.
+func (s *UserInfoQueryHandler) Handle() *UserInfo {
+	s.panicIfScopeIsInsufficient()
+
+	return userInfo(s.user(), s.verification().Token.Payload.Scope)
+}
+
+// 1-й уровень абстракции.
+
+func (s *UserInfoQueryHandler) panicIfScopeIsInsufficient() {
+	if !scopeContains(s.verification().Token.Payload.Scope, OPENID_SCOPE) {
+		panic(&OAuthError{
+			Code:        "insufficient_scope",
+			Description: fmt.Sprintf("the access token was not issued for the %s scope", OPENID_SCOPE),
+			Err:         ErrForbidden,
+		})
+	}
+}
+
+func (s *UserInfoQueryHandler) user() *users.User {
+	if s._user == nil {
+		s._user = s.getUser()
+	}
+
+	return s._user
+}
+
+// 2-й уровень абстракции.
+
+func (s *UserInfoQueryHandler) verification() *AccessTokenVerificationResult {
+	if s._verification == nil {
+		s._verification = verifyAccessTokenOfAnyClient(s.Query.AccessToken)
+	}
+
+	return s._verification
+}
+
+func (s *UserInfoQueryHandler) getUser() *users.User {
+	user, err := services.UsersRepository().Get(s.verification().Auth.UserId)
+	if err != nil {
+		panic(err)
+	}
+
+	return user
+}
+
+// Получить утверждения о пользователе, разрешенные указанными областями доступа.
+func userInfo(user *users.User, scope string) *UserInfo {
+	result := &UserInfo{
+		Subject: strconv.Itoa(int(user.Id)),
+	}
+
+	if scopeContains(scope, EMAIL_SCOPE) {
+		result.Email = user.Email
+		result.EmailVerified = &user.EmailVerified
+	}
+
+	return result
+}
+
+// Проверить, что среди областей доступа, разделенных пробелами, есть указанная.
+func scopeContains(scope string, value string) bool {
+	return slices.Contains(strings.Fields(scope), value)
+}
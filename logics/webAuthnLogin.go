@@ -0,0 +1,217 @@
+package logics
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"goauth/data/credentials"
+	"goauth/data/users"
+	"goauth/logics/services"
+	"goauth/webauthn"
+	"log"
+)
+
+// Команда на начало аутентификации учетными данными WebAuthn (passkey).
+type WebAuthnLoginOptionsCommand struct{}
+
+// Результат начала аутентификации учетными данными WebAuthn.
+type WebAuthnLoginOptionsResult struct {
+	// Токен церемонии, который возвращается вместе с подписью аутентификатора.
+	CeremonyToken string
+
+	// Параметры для `navigator.credentials.get()`.
+	Options webauthn.RequestOptions
+}
+
+// Обработчик команды на начало аутентификации учетными данными WebAuthn.
+//
+// Пользователь не указывается: аутентификатор сам предлагает обнаруживаемые учетные данные для сервиса.
+type WebAuthnLoginOptionsCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *WebAuthnLoginOptionsCommand
+}
+
+// Обработать команду на начало аутентификации учетными данными WebAuthn.
+func (s *WebAuthnLoginOptionsCommandHandler) Handle() *WebAuthnLoginOptionsResult {
+	challenge, token := beginWebAuthnCeremony(services.WebAuthnAuthenticationTokenIssuer(), 0)
+
+	return &WebAuthnLoginOptionsResult{
+		CeremonyToken: token,
+		Options:       services.RelyingParty().RequestOptions(challenge),
+	}
+}
+
+// Команда на завершение аутентификации учетными данными WebAuthn.
+type WebAuthnLoginCommand struct {
+	// Токен церемонии, полученный при начале аутентификации.
+	CeremonyToken string
+
+	// Учетные данные с подписью, возвращенные `navigator.credentials.get()`.
+	Credential webauthn.Credential
+
+	// IP адрес пользователя.
+	UserIp string
+
+	// Название устройства пользователя.
+	DeviceName string
+
+	// Значение заголовка User-Agent запроса пользователя.
+	UserAgent string
+}
+
+// Обработчик команды на завершение аутентификации учетными данными WebAuthn.
+type WebAuthnLoginCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *WebAuthnLoginCommand
+
+	_credential        *credentials.Credential
+	_user              *users.User
+	_authenticatorData *webauthn.AuthenticatorData
+}
+
+// Способы аутентификации (RFC 8176), используемые при аутентификации учетными данными WebAuthn.
+var webAuthnAmr = []string{"hwk"}
+
+// Обработать команду на завершение аутентификации учетными данными WebAuthn.
+//
+// Проверка пользователя аутентификатором (PIN, биометрия) засчитывается как второй фактор.
+// Без нее пользователю с включенным TOTP выдается токен второго шага аутентификации.
+func (s *WebAuthnLoginCommandHandler) Handle() *LoginResult {
+	s.panicIfUserHandleDoesNotMatch()
+
+	s.updateSignCount()
+
+	if !s.userIsVerified() && s.user().TotpEnabled {
+		return createMfaChallenge(s.user().Id, webAuthnAmr, s.Command.DeviceName)
+	}
+
+	return s.result()
+}
+
+// 1-й уровень абстракции.
+
+func (s *WebAuthnLoginCommandHandler) panicIfUserHandleDoesNotMatch() {
+	if s.Command.Credential.Response.UserHandle == "" {
+		return
+	}
+
+	userHandle, err := s.Command.Credential.UserHandle()
+	if err != nil || !bytes.Equal(userHandle, webAuthnUserHandle(s.credential().UserId)) {
+		panicBecauseOfInvalidWebAuthnCredential()
+	}
+}
+
+func (s *WebAuthnLoginCommandHandler) updateSignCount() {
+	updated, err := services.CredentialsRepository().UpdateSignCount(s.credential().Id, int64(s.authenticatorData().SignCount))
+	if err != nil {
+		panic(err)
+	}
+
+	if !updated {
+		s.panicBecauseOfClonedAuthenticator()
+	}
+}
+
+func (s *WebAuthnLoginCommandHandler) userIsVerified() bool {
+	return s.authenticatorData().Flags&webauthn.FLAG_USER_VERIFIED != 0
+}
+
+func (s *WebAuthnLoginCommandHandler) result() *LoginResult {
+	amr := webAuthnAmr
+	if s.userIsVerified() {
+		amr = append(amr[:len(amr):len(amr)], "mfa")
+	}
+
+	return createSession(&SessionCreationCommand{
+		UserId:     s.user().Id,
+		UserIp:     s.Command.UserIp,
+		DeviceName: s.Command.DeviceName,
+		UserAgent:  s.Command.UserAgent,
+		Amr:        amr,
+	})
+}
+
+// 2-й уровень абстракции.
+
+func (s *WebAuthnLoginCommandHandler) authenticatorData() *webauthn.AuthenticatorData {
+	if s._authenticatorData == nil {
+		s._authenticatorData = s.verifyAssertion()
+	}
+
+	return s._authenticatorData
+}
+
+func (s *WebAuthnLoginCommandHandler) user() *users.User {
+	if s._user == nil {
+		s._user = s.getUser()
+	}
+
+	return s._user
+}
+
+// 3-й уровень абстракции.
+
+func (s *WebAuthnLoginCommandHandler) verifyAssertion() *webauthn.AuthenticatorData {
+	token := decodeCeremonyToken(services.WebAuthnAuthenticationTokenIssuer(), s.Command.CeremonyToken)
+
+	authenticatorData, err := services.RelyingParty().VerifyAssertion(consumeWebAuthnChallenge(token.Payload), s.Command.Credential,
+		s.credential().PublicKey, uint32(s.credential().SignCount))
+	if errors.Is(err, webauthn.ErrSignCount) {
+		s.panicBecauseOfClonedAuthenticator()
+	}
+
+	if err != nil {
+		panic(fmt.Errorf("%w: %w", ErrUnauthorized, err))
+	}
+
+	return authenticatorData
+}
+
+func (s *WebAuthnLoginCommandHandler) getUser() *users.User {
+	user, err := services.UsersRepository().Get(s.credential().UserId)
+	if err != nil {
+		panic(err)
+	}
+
+	return user
+}
+
+func (s *WebAuthnLoginCommandHandler) credential() *credentials.Credential {
+	if s._credential == nil {
+		s._credential = s.getCredential()
+	}
+
+	return s._credential
+}
+
+// 4-й уровень абстракции.
+
+func (s *WebAuthnLoginCommandHandler) getCredential() *credentials.Credential {
+	credentialId, err := s.Command.Credential.RawId()
+	if err != nil {
+		panicBecauseOfInvalidWebAuthnCredential()
+	}
+
+	credential, err := services.CredentialsRepository().GetByCredentialId(credentialId)
+	if errors.Is(err, sql.ErrNoRows) {
+		panicBecauseOfInvalidWebAuthnCredential()
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	return credential
+}
+
+func (s *WebAuthnLoginCommandHandler) panicBecauseOfClonedAuthenticator() {
+	log.Printf("::: SECURITY: sign count of WEBAUTHN_CREDENTIALS record %d of user %d has not increased, the authenticator may be cloned",
+		s.credential().Id, s.credential().UserId)
+
+	panicBecauseOfInvalidWebAuthnCredential()
+}
+
+func panicBecauseOfInvalidWebAuthnCredential() {
+	panic(fmt.Errorf("%w: the WebAuthn credential is unknown or invalid", ErrUnauthorized))
+}
@@ -0,0 +1,205 @@
+package logics
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"goauth/data/credentials"
+	"goauth/data/users"
+	"goauth/data/webauthnchallenges"
+	"goauth/logics/services"
+	"goauth/tokens/ceremony"
+	"goauth/tokens/jwt"
+	"goauth/webauthn"
+	"strconv"
+	"time"
+)
+
+// Команда на начало регистрации учетных данных WebAuthn (passkey).
+type WebAuthnRegistrationOptionsCommand struct {
+	// ACCESS токен пользователя.
+	AccessToken string
+}
+
+// Результат начала регистрации учетных данных WebAuthn.
+type WebAuthnRegistrationOptionsResult struct {
+	// Токен церемонии, который возвращается вместе с созданными учетными данными.
+	CeremonyToken string
+
+	// Параметры для `navigator.credentials.create()`.
+	Options webauthn.CreationOptions
+}
+
+// Обработчик команды на начало регистрации учетных данных WebAuthn.
+type WebAuthnRegistrationOptionsCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *WebAuthnRegistrationOptionsCommand
+
+	_user *users.User
+}
+
+// Обработать команду на начало регистрации учетных данных WebAuthn.
+func (s *WebAuthnRegistrationOptionsCommandHandler) Handle() *WebAuthnRegistrationOptionsResult {
+	challenge, token := beginWebAuthnCeremony(services.WebAuthnRegistrationTokenIssuer(), s.user().Id)
+
+	return &WebAuthnRegistrationOptionsResult{
+		CeremonyToken: token,
+		Options:       services.RelyingParty().CreationOptions(challenge, webAuthnUserHandle(s.user().Id), s.user().Email, s.registeredCredentialIds()),
+	}
+}
+
+// 1-й уровень абстракции.
+
+func (s *WebAuthnRegistrationOptionsCommandHandler) registeredCredentialIds() [][]byte {
+	registered, err := services.CredentialsRepository().ListByUser(s.user().Id)
+	if err != nil {
+		panic(err)
+	}
+
+	result := [][]byte{}
+	for _, credential := range registered {
+		result = append(result, credential.CredentialId)
+	}
+
+	return result
+}
+
+// 2-й уровень абстракции.
+
+func (s *WebAuthnRegistrationOptionsCommandHandler) user() *users.User {
+	if s._user == nil {
+		s._user = getVerifiedUser(s.Command.AccessToken)
+	}
+
+	return s._user
+}
+
+// Команда на завершение регистрации учетных данных WebAuthn.
+type WebAuthnRegistrationCommand struct {
+	// ACCESS токен пользователя.
+	AccessToken string
+
+	// Токен церемонии, полученный при начале регистрации.
+	CeremonyToken string
+
+	// Название учетных данных, например, название устройства.
+	Name string
+
+	// Учетные данные, созданные `navigator.credentials.create()`.
+	Credential webauthn.Credential
+}
+
+// Обработчик команды на завершение регистрации учетных данных WebAuthn.
+type WebAuthnRegistrationCommandHandler struct {
+	// Обрабатываемая команда.
+	Command *WebAuthnRegistrationCommand
+
+	_token *jwt.Jwt[ceremony.CeremonyTokenPayload]
+}
+
+// Обработать команду на завершение регистрации учетных данных WebAuthn.
+func (s *WebAuthnRegistrationCommandHandler) Handle() {
+	s.panicIfTokenIsIssuedToAnotherUser()
+
+	s.createCredential(s.verifyCredential())
+}
+
+// 1-й уровень абстракции.
+
+func (s *WebAuthnRegistrationCommandHandler) panicIfTokenIsIssuedToAnotherUser() {
+	if s.token().Payload.Subject != verifyAccessToken(s.Command.AccessToken).Auth.UserId {
+		panic(fmt.Errorf("%w: the ceremony token is issued to another user", ErrUnauthorized))
+	}
+}
+
+func (s *WebAuthnRegistrationCommandHandler) verifyCredential() *webauthn.AuthenticatorData {
+	authenticatorData, err := services.RelyingParty().VerifyRegistration(consumeWebAuthnChallenge(s.token().Payload), s.Command.Credential)
+	if err != nil {
+		panic(fmt.Errorf("%w: %w", ErrInvalidRequest, err))
+	}
+
+	return authenticatorData
+}
+
+func (s *WebAuthnRegistrationCommandHandler) createCredential(authenticatorData *webauthn.AuthenticatorData) {
+	_, err := services.CredentialsRepository().Create(credentials.Credential{
+		UserId:       s.token().Payload.Subject,
+		CredentialId: authenticatorData.AttestedCredential.Id,
+		PublicKey:    authenticatorData.AttestedCredential.PublicKey,
+		SignCount:    int64(authenticatorData.SignCount),
+		Name:         s.Command.Name,
+	})
+
+	if errors.Is(err, credentials.ErrCredentialTaken) {
+		panic(fmt.Errorf("%w: %w", ErrInvalidRequest, err))
+	}
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// 2-й уровень абстракции.
+
+func (s *WebAuthnRegistrationCommandHandler) token() *jwt.Jwt[ceremony.CeremonyTokenPayload] {
+	if s._token == nil {
+		s._token = decodeCeremonyToken(services.WebAuthnRegistrationTokenIssuer(), s.Command.CeremonyToken)
+	}
+
+	return s._token
+}
+
+// Начать церемонию WebAuthn: сгенерировать случайный вызов, сохранить его в таблице WEBAUTHN_CHALLENGES и выдать токен церемонии.
+func beginWebAuthnCeremony(issuer ceremony.Issuer, userId int32) ([]byte, string) {
+	challenge := make([]byte, 32)
+
+	_, err := rand.Read(challenge)
+	if err != nil {
+		panic(err)
+	}
+
+	saved, err := services.WebAuthnChallengesRepository().Create(webauthnchallenges.WebAuthnChallenge{
+		Challenge: challenge,
+		ExpiresAt: time.Now().Add(issuer.Lifetime),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	token, err := issuer.Encode(ceremony.New(issuer, userId, saved.Id))
+	if err != nil {
+		panic(err)
+	}
+
+	return challenge, token
+}
+
+// Использовать вызов, сохраненный при начале церемонии. Вызов удаляется, поэтому церемонию нельзя завершить повторно.
+func consumeWebAuthnChallenge(payload ceremony.CeremonyTokenPayload) []byte {
+	consumed, err := services.WebAuthnChallengesRepository().Consume(payload.Id)
+	if errors.Is(err, sql.ErrNoRows) {
+		panic(fmt.Errorf("%w: the WebAuthn ceremony has expired or has already been completed", ErrUnauthorized))
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	return consumed.Challenge
+}
+
+// Получить идентификатор пользователя, сохраняемый в учетных данных WebAuthn: десятичную запись идентификатора в таблице USERS.
+func webAuthnUserHandle(userId int32) []byte {
+	return []byte(strconv.Itoa(int(userId)))
+}
+
+// Раскодировать и проверить токен церемонии WebAuthn.
+func decodeCeremonyToken(issuer ceremony.Issuer, encodedToken string) *jwt.Jwt[ceremony.CeremonyTokenPayload] {
+	token, err := issuer.Decode(encodedToken)
+	if err != nil {
+		panic(fmt.Errorf("%w: %w", ErrUnauthorized, err))
+	}
+
+	return token
+}
@@ -3,18 +3,70 @@ package main
 import (
 	"fmt"
 	"goauth/api"
+	"goauth/logics/services"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/auth/login", api.HandleLogin)
+	mux.HandleFunc("/auth/register", api.HandleRegistration)
+	mux.HandleFunc("/auth/verify-email", api.HandleEmailVerification)
+	mux.HandleFunc("/auth/password/forgot", api.HandleForgotPassword)
+	mux.HandleFunc("/auth/password/reset", api.HandlePasswordReset)
+	mux.HandleFunc("/auth/mfa/totp", api.HandleTotpEnrollment)
+	mux.HandleFunc("/auth/mfa/totp/confirm", api.HandleTotpConfirmation)
+	mux.HandleFunc("/auth/mfa/verify", api.HandleMfaVerification)
+	mux.HandleFunc("/auth/webauthn/register/begin", api.HandleWebAuthnRegistrationOptions)
+	mux.HandleFunc("/auth/webauthn/register/finish", api.HandleWebAuthnRegistration)
+	mux.HandleFunc("/auth/webauthn/login/begin", api.HandleWebAuthnLoginOptions)
+	mux.HandleFunc("/auth/webauthn/login/finish", api.HandleWebAuthnLogin)
+	mux.HandleFunc("/auth/magic-link", api.HandleMagicLink)
+	mux.HandleFunc("/auth/magic-link/consume", api.HandleMagicLinkConsumption)
 	mux.HandleFunc("/auth/refresh", api.HandleRefresh)
+	mux.HandleFunc("/auth/logout", api.HandleLogout)
+	mux.HandleFunc("/auth/introspect", api.HandleIntrospection)
+	mux.HandleFunc("/auth/revoke", api.HandleRevocation)
+	mux.HandleFunc("/auth/sessions", api.HandleSessions)
+	mux.HandleFunc("/auth/sessions/{id}", api.HandleSessionRevocation)
+	mux.HandleFunc("/auth/sessions/revoke-others", api.HandleOtherSessionsRevocation)
+	mux.HandleFunc("/oauth/authorize", api.HandleAuthorization)
+	mux.HandleFunc("/oauth/token", api.HandleToken)
+	mux.HandleFunc("/admin/clients", api.HandleClients)
+	mux.HandleFunc("/admin/clients/{id}", api.HandleClient)
+	mux.HandleFunc("/admin/clients/{id}/secret", api.HandleClientSecretRotation)
+	mux.HandleFunc("/userinfo", api.HandleUserInfo)
+	mux.HandleFunc("/.well-known/jwks.json", api.HandleJwks)
+	mux.HandleFunc("/.well-known/openid-configuration", api.HandleOpenIdConfiguration)
 
 	handler := api.ErrorsHandler(mux)
 
+	// Ключи ID токенов проверяются при запуске: с ключом HMAC сервис не запускается.
+	services.IdTokenIssuer()
+
+	go reloadTokenKeysOnSignal()
+
 	fmt.Println("::: Сервер запущен по адресу http://localhost:8080")
 
 	http.ListenAndServe(":8080", handler)
 }
+
+// Перечитывать наборы ключей подписи токенов из файлов при получении сигнала SIGHUP.
+func reloadTokenKeysOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	for range signals {
+		err := services.ReloadTokenKeys()
+		if err != nil {
+			fmt.Println("::: Не удалось перечитать ключи подписи токенов:", err)
+			continue
+		}
+
+		fmt.Println("::: Ключи подписи токенов перечитаны")
+	}
+}
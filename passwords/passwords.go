@@ -0,0 +1,135 @@
+package passwords
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Пароль не соответствует хэшу.
+var ErrMismatch = errors.New("the password does not match the hash")
+
+// Формат хэша не поддерживается.
+var ErrUnsupportedHash = errors.New("the password hash format is not supported")
+
+// Параметры хэширования паролей алгоритмом Argon2id.
+//
+// Параметры сохраняются вместе с хэшем в формате PHC, поэтому их изменение не мешает проверке ранее вычисленных хэшей.
+type Parameters struct {
+	// Объем памяти в КиБ.
+	Memory uint32
+
+	// Количество проходов.
+	Iterations uint32
+
+	// Степень параллелизма.
+	Parallelism uint8
+
+	// Длина соли в байтах.
+	SaltLength uint32
+
+	// Длина хэша в байтах.
+	KeyLength uint32
+}
+
+// Параметры хэширования, рекомендуемые RFC 9106 для систем с ограниченной памятью.
+var DefaultParameters = Parameters{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 4,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Вычислить хэш пароля в формате PHC: `$argon2id$v=19$m=<память>,t=<проходы>,p=<параллелизм>$<соль>$<хэш>`.
+func (s Parameters) Hash(password string) (string, error) {
+	salt := make([]byte, s.SaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, s.Iterations, s.Memory, s.Parallelism, s.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, s.Memory, s.Iterations, s.Parallelism,
+		encode(salt), encode(key)), nil
+}
+
+// Проверить, что хэш вычислен с другими параметрами или другим алгоритмом и его следует вычислить заново.
+func (s Parameters) NeedsRehash(hash string) bool {
+	parameters, _, _, err := parse(hash)
+	if err != nil {
+		return true
+	}
+
+	return parameters != s
+}
+
+// Проверить пароль по хэшу в формате PHC (Argon2id) или BCRYPT. Сравнение выполняется за постоянное время.
+func Verify(password string, hash string) error {
+	if strings.HasPrefix(hash, "$2") {
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatch
+		}
+
+		return err
+	}
+
+	parameters, salt, key, err := parse(hash)
+	if err != nil {
+		return err
+	}
+
+	actual := argon2.IDKey([]byte(password), salt, parameters.Iterations, parameters.Memory, parameters.Parallelism, parameters.KeyLength)
+	if subtle.ConstantTimeCompare(actual, key) != 1 {
+		return ErrMismatch
+	}
+
+	return nil
+}
+
+// Разобрать хэш в формате PHC.
+func parse(hash string) (Parameters, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return Parameters{}, nil, nil, ErrUnsupportedHash
+	}
+
+	var version int
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return Parameters{}, nil, nil, ErrUnsupportedHash
+	}
+
+	var parameters Parameters
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &parameters.Memory, &parameters.Iterations, &parameters.Parallelism)
+	if err != nil || parameters.Iterations == 0 || parameters.Parallelism == 0 {
+		return Parameters{}, nil, nil, ErrUnsupportedHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Parameters{}, nil, nil, ErrUnsupportedHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Parameters{}, nil, nil, ErrUnsupportedHash
+	}
+
+	parameters.SaltLength = uint32(len(salt))
+	parameters.KeyLength = uint32(len(key))
+
+	return parameters, salt, key, nil
+}
+
+func encode(value []byte) string {
+	return base64.RawStdEncoding.EncodeToString(value)
+}
@@ -0,0 +1,89 @@
+package passwords
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Параметры с небольшим объемом памяти, чтобы тесты выполнялись быстро.
+var testParameters = Parameters{
+	Memory:      64,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestHashAndVerify(t *testing.T) {
+	hash, err := testParameters.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("Hash() = %s", hash)
+	}
+
+	err = Verify("correct horse", hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Verify("wrong horse", hash)
+	if !errors.Is(err, ErrMismatch) {
+		t.Fatalf("Verify() with a wrong password = %v, want ErrMismatch", err)
+	}
+}
+
+func TestVerifyBcrypt(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Verify("correct horse", string(hash))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Verify("wrong horse", string(hash))
+	if !errors.Is(err, ErrMismatch) {
+		t.Fatalf("Verify() with a wrong password = %v, want ErrMismatch", err)
+	}
+}
+
+func TestVerifyRejectsMalformedHash(t *testing.T) {
+	for _, hash := range []string{"", "plain", "$argon2i$v=19$m=64,t=1,p=1$c2FsdA$a2V5", "$argon2id$v=16$m=64,t=1,p=1$c2FsdA$a2V5", "$argon2id$v=19$m=64,t=0,p=1$c2FsdA$a2V5"} {
+		err := Verify("password", hash)
+		if !errors.Is(err, ErrUnsupportedHash) {
+			t.Errorf("Verify() with %q = %v, want ErrUnsupportedHash", hash, err)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	hash, err := testParameters.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if testParameters.NeedsRehash(hash) {
+		t.Error("NeedsRehash() for a hash with the same parameters = true")
+	}
+
+	if !DefaultParameters.NeedsRehash(hash) {
+		t.Error("NeedsRehash() for a hash with other parameters = false")
+	}
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !DefaultParameters.NeedsRehash(string(bcryptHash)) {
+		t.Error("NeedsRehash() for a BCRYPT hash = false")
+	}
+}
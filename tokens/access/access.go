@@ -1,112 +1,57 @@
 package access
 
 import (
-	"crypto/hmac"
-	"crypto/sha512"
-	"fmt"
 	"goauth/tokens/jwt"
-	"hash"
-	"strings"
-	"time"
+	"strconv"
 )
 
 // Полезная нагрузка JWT токена доступа.
 type AccessTokenPayload struct {
-	// Идентификатор пользователя, которому был выдан токен.
-	Subject int32 `json:"sub"`
+	// Субъект токена: десятичный идентификатор пользователя или идентификатор клиента OAuth 2.0 для гранта client_credentials.
+	Subject string `json:"sub"`
 
-	// Имя издателя токена.
-	Issuer string `json:"iss"`
+	// Зарегистрированные утверждения токена.
+	jwt.RegisteredClaims
 
-	// Момент времени, когда токен был выдан в формате UNIX.
-	IssuedAt int64 `json:"iat"`
+	// Идентификатор токена: идентификатор записи в таблице AUTHS или, для токенов, выданных клиентам по гранту client_credentials,
+	// в таблице CLIENT_TOKENS.
+	Id int32 `json:"jti,omitempty"`
 
-	// Момент времени, до которого токен считается действительным в формате UNIX.
-	ExpirationTime int64 `json:"exp"`
+	// Идентификатор клиента OAuth 2.0, которому был выдан токен (RFC 9068).
+	ClientId string `json:"client_id,omitempty"`
 
-	// Идентификатор токена.
-	Id int32 `json:"jti"`
-}
-
-// Вспомогательное средство для издания JWT токенов доступа.
-type Issuer struct {
-	// Имя издателя токена.
-	Name string
-
-	// Ключ, с помощью которого подписываются токены.
-	Key string
-
-	// Время жизни токена в минутах.
-	TokenLifeTimeInMinutes int
-}
-
-// Выдать новый токен с указанными параметрами.
-func (s Issuer) New(userId int32, tokenId int32) jwt.Jwt[AccessTokenPayload] {
-	now := time.Now()
-
-	result := jwt.Jwt[AccessTokenPayload]{
-		Header: jwt.Header{
-			Algorythm: "HS512",
-			Type:      "JWT",
-		},
-		Payload: AccessTokenPayload{
-			Issuer:         s.Name,
-			IssuedAt:       now.Unix(),
-			ExpirationTime: now.Add(time.Duration(s.TokenLifeTimeInMinutes) * time.Minute).Unix(),
-			Subject:        userId,
-			Id:             tokenId,
-		},
-	}
+	// Области доступа, разделенные пробелами.
+	Scope string `json:"scope,omitempty"`
 
-	return result
-}
+	// Роли пользователя.
+	Roles []string `json:"roles,omitempty"`
 
-// Закодировать указанный токен с помощью определенного внутри средства алгоритма.
-func (s Issuer) Encode(token jwt.Jwt[AccessTokenPayload]) (string, error) {
-	encodedToken, err := token.Encoded(s.Hash())
-	if err != nil {
-		return "", err
-	}
+	// Способы аутентификации, использованные при начале сеанса (RFC 8176).
+	Amr []string `json:"amr,omitempty"`
 
-	return encodedToken, nil
+	// Дополнительные утверждения, кодируемые на верхнем уровне полезной нагрузки.
+	Custom map[string]any `json:"-"`
 }
 
-// Декодировать указанный закодированный токен.
-func (s Issuer) Decode(encodedToken string) (*jwt.Jwt[AccessTokenPayload], error) {
-	parts := strings.Split(encodedToken, ".")
-	if len(parts) != 3 {
-		return nil, fmt.Errorf("the number of encoded token %s parts is not equal to 3", encodedToken)
-	}
-
-	decodedHeader, err := jwt.Decode[jwt.Header](parts[0])
-	if err != nil {
-		return nil, err
-	}
-
-	decodedPayload, err := jwt.Decode[AccessTokenPayload](parts[1])
-	if err != nil {
-		return nil, err
-	}
-
-	decodedToken := &jwt.Jwt[AccessTokenPayload]{
-		Header:  *decodedHeader,
-		Payload: *decodedPayload,
-	}
-
-	decodedTokenSignature, err := decodedToken.Signature(s.Hash())
-	if err != nil {
-		return nil, err
-	}
-
-	encodedTokenSignature := parts[2]
-	if encodedTokenSignature != decodedTokenSignature {
-		return nil, fmt.Errorf("the signature of the encoded token %s is not equal to the calculated signature of the decoded token", encodedToken)
-	}
-
-	return decodedToken, nil
+// Вспомогательное средство для издания JWT токенов доступа.
+type Issuer = jwt.Issuer[AccessTokenPayload]
+
+// Выдать новый токен доступа с указанными параметрами.
+func New(issuer Issuer, userId int32, tokenId int32) jwt.Jwt[AccessTokenPayload] {
+	return issuer.New(AccessTokenPayload{
+		RegisteredClaims: issuer.Registered(),
+		Subject:          strconv.Itoa(int(userId)),
+		Id:               tokenId,
+	})
 }
 
-// Получить хэш-функцию, используемую издателем токенов.
-func (s Issuer) Hash() hash.Hash {
-	return hmac.New(sha512.New, []byte(s.Key))
+// Выдать новый токен доступа клиенту OAuth 2.0, действующему от своего имени. Такой токен связан с записью в таблице CLIENT_TOKENS,
+// а не AUTHS.
+func NewForClient(issuer Issuer, clientId string, tokenId int32) jwt.Jwt[AccessTokenPayload] {
+	return issuer.New(AccessTokenPayload{
+		RegisteredClaims: issuer.Registered(),
+		Subject:          clientId,
+		Id:               tokenId,
+		ClientId:         clientId,
+	})
 }
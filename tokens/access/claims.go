@@ -0,0 +1,119 @@
+package access
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Максимальное количество дополнительных утверждений в ACCESS токене.
+const MAX_CUSTOM_CLAIMS_COUNT = 32
+
+// Максимальный размер дополнительных утверждений ACCESS токена в формате JSON в байтах.
+const MAX_CUSTOM_CLAIMS_SIZE_IN_BYTES = 4096
+
+// Закодировать полезную нагрузку, добавив дополнительные утверждения на верхний уровень.
+func (s AccessTokenPayload) MarshalJSON() ([]byte, error) {
+	type plain AccessTokenPayload
+
+	afterMarshalling, err := json.Marshal(plain(s))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(s.Custom) == 0 {
+		return afterMarshalling, nil
+	}
+
+	err = validateCustomClaims(s.Custom)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := map[string]any{}
+	err = json.Unmarshal(afterMarshalling, &claims)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range s.Custom {
+		if isReservedClaim(name) {
+			return nil, fmt.Errorf("the custom claim %s conflicts with a reserved claim", name)
+		}
+
+		claims[name] = value
+	}
+
+	return json.Marshal(claims)
+}
+
+// Декодировать полезную нагрузку, собрав неизвестные утверждения в дополнительные.
+//
+// Числовой субъект токенов, выданных до перехода на строковый субъект, приводится к строке.
+func (s *AccessTokenPayload) UnmarshalJSON(value []byte) error {
+	type plain AccessTokenPayload
+
+	claims := map[string]any{}
+	err := json.Unmarshal(value, &claims)
+	if err != nil {
+		return err
+	}
+
+	if subject, ok := claims["sub"].(float64); ok {
+		claims["sub"] = strconv.FormatFloat(subject, 'f', -1, 64)
+
+		value, err = json.Marshal(claims)
+		if err != nil {
+			return err
+		}
+	}
+
+	var result plain
+	err = json.Unmarshal(value, &result)
+	if err != nil {
+		return err
+	}
+
+	for name, claim := range claims {
+		if isReservedClaim(name) {
+			continue
+		}
+
+		if result.Custom == nil {
+			result.Custom = map[string]any{}
+		}
+
+		result.Custom[name] = claim
+	}
+
+	*s = AccessTokenPayload(result)
+
+	return nil
+}
+
+// Зарезервированные утверждения, которые не могут быть переопределены дополнительными.
+var reservedClaims = map[string]bool{
+	"iss": true, "sub": true, "aud": true, "exp": true, "nbf": true, "iat": true, "jti": true,
+	"scope": true, "roles": true, "amr": true, "client_id": true,
+}
+
+func isReservedClaim(name string) bool {
+	return reservedClaims[name]
+}
+
+func validateCustomClaims(custom map[string]any) error {
+	if len(custom) > MAX_CUSTOM_CLAIMS_COUNT {
+		return fmt.Errorf("the number of custom claims %d exceeds the limit of %d", len(custom), MAX_CUSTOM_CLAIMS_COUNT)
+	}
+
+	afterMarshalling, err := json.Marshal(custom)
+	if err != nil {
+		return err
+	}
+
+	if len(afterMarshalling) > MAX_CUSTOM_CLAIMS_SIZE_IN_BYTES {
+		return fmt.Errorf("the size of custom claims %d bytes exceeds the limit of %d bytes", len(afterMarshalling), MAX_CUSTOM_CLAIMS_SIZE_IN_BYTES)
+	}
+
+	return nil
+}
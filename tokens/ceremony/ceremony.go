@@ -0,0 +1,37 @@
+package ceremony
+
+import (
+	"goauth/tokens/jwt"
+)
+
+// Получатель токенов церемонии регистрации учетных данных WebAuthn. Отличает их от ACCESS токенов, подписанных тем же ключом.
+const REGISTRATION_AUDIENCE = "webauthn-registration"
+
+// Получатель токенов церемонии аутентификации WebAuthn. Отличает их от ACCESS токенов, подписанных тем же ключом.
+const AUTHENTICATION_AUDIENCE = "webauthn-authentication"
+
+// Полезная нагрузка JWT токена церемонии WebAuthn.
+//
+// Связывает завершение церемонии с вызовом, сохраненным при ее начале в таблице WEBAUTHN_CHALLENGES.
+type CeremonyTokenPayload struct {
+	// Идентификатор пользователя, регистрирующего учетные данные. 0 для церемонии аутентификации.
+	Subject int32 `json:"sub,omitempty"`
+
+	// Зарегистрированные утверждения токена.
+	jwt.RegisteredClaims
+
+	// Идентификатор записи в таблице WEBAUTHN_CHALLENGES, через которую обеспечивается однократное использование вызова.
+	Id int32 `json:"jti"`
+}
+
+// Вспомогательное средство для издания JWT токенов церемонии WebAuthn.
+type Issuer = jwt.Issuer[CeremonyTokenPayload]
+
+// Выдать новый токен церемонии WebAuthn для вызова с указанным идентификатором.
+func New(issuer Issuer, userId int32, challengeId int32) jwt.Jwt[CeremonyTokenPayload] {
+	return issuer.New(CeremonyTokenPayload{
+		RegisteredClaims: issuer.Registered(),
+		Subject:          userId,
+		Id:               challengeId,
+	})
+}
@@ -0,0 +1,43 @@
+package challenge
+
+import (
+	"goauth/tokens/jwt"
+)
+
+// Получатель токенов второго шага аутентификации. Отличает их от ACCESS токенов, подписанных тем же ключом.
+const AUDIENCE = "mfa-challenge"
+
+// Полезная нагрузка JWT токена второго шага аутентификации.
+//
+// Выдается после проверки первого фактора и обменивается на пару токенов после проверки второго.
+type MfaChallengeTokenPayload struct {
+	// Идентификатор пользователя, прошедшего первый шаг аутентификации.
+	Subject int32 `json:"sub"`
+
+	// Зарегистрированные утверждения токена.
+	jwt.RegisteredClaims
+
+	// Идентификатор записи в таблице MFA_CHALLENGES, через которую обеспечивается однократное использование токена
+	// и ограничивается количество попыток предъявить второй фактор.
+	Id int32 `json:"jti"`
+
+	// Способы аутентификации, использованные на первом шаге (RFC 8176).
+	Amr []string `json:"amr"`
+
+	// Название устройства, указанное пользователем на первом шаге.
+	DeviceName string `json:"dvn,omitempty"`
+}
+
+// Вспомогательное средство для издания JWT токенов второго шага аутентификации.
+type Issuer = jwt.Issuer[MfaChallengeTokenPayload]
+
+// Выдать новый токен второго шага аутентификации для указанного пользователя.
+func New(issuer Issuer, userId int32, challengeId int32, amr []string, deviceName string) jwt.Jwt[MfaChallengeTokenPayload] {
+	return issuer.New(MfaChallengeTokenPayload{
+		RegisteredClaims: issuer.Registered(),
+		Subject:          userId,
+		Id:               challengeId,
+		Amr:              amr,
+		DeviceName:       deviceName,
+	})
+}
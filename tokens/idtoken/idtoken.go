@@ -0,0 +1,47 @@
+package idtoken
+
+import (
+	"goauth/tokens/jwt"
+	"strconv"
+)
+
+// Полезная нагрузка ID токена OpenID Connect (OpenID Connect Core 1.0, раздел 2).
+type IdTokenPayload struct {
+	// Десятичный идентификатор пользователя, который был аутентифицирован.
+	Subject string `json:"sub"`
+
+	// Зарегистрированные утверждения токена. Получатель токена — идентификатор клиента OAuth 2.0.
+	jwt.RegisteredClaims
+
+	// Момент времени аутентификации пользователя в формате UNIX: момент начала сеанса, в рамках которого выдан код авторизации.
+	AuthTime int64 `json:"auth_time"`
+
+	// Значение, переданное клиентом в запросе на авторизацию для защиты от повторного использования токена.
+	Nonce string `json:"nonce,omitempty"`
+
+	// Левая половина хэша ACCESS токена, выданного вместе с ID токеном, в кодировке Base64URL.
+	AccessTokenHash string `json:"at_hash,omitempty"`
+
+	// Способы аутентификации, использованные при начале сеанса (RFC 8176).
+	Amr []string `json:"amr,omitempty"`
+
+	// Адрес электронной почты пользователя. Только для области доступа email.
+	Email string `json:"email,omitempty"`
+
+	// Признак подтвержденного адреса электронной почты. Только для области доступа email.
+	EmailVerified *bool `json:"email_verified,omitempty"`
+}
+
+// Вспомогательное средство для издания ID токенов.
+type Issuer = jwt.Issuer[IdTokenPayload]
+
+// Выдать новый ID токен указанного пользователя для указанного клиента.
+func New(issuer Issuer, userId int32, clientId string) jwt.Jwt[IdTokenPayload] {
+	registeredClaims := issuer.Registered()
+	registeredClaims.Audience = jwt.Audience{clientId}
+
+	return issuer.New(IdTokenPayload{
+		RegisteredClaims: registeredClaims,
+		Subject:          strconv.Itoa(int(userId)),
+	})
+}
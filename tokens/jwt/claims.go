@@ -0,0 +1,148 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Срок действия токена истек.
+var ErrExpired = errors.New("the token has expired")
+
+// Срок действия токена еще не наступил.
+var ErrNotYetValid = errors.New("the token is not valid yet")
+
+// Токен выдан издателем, которому не доверяет получатель.
+var ErrIssuerMismatch = errors.New("the token issuer is not trusted")
+
+// Токен выдан для другого получателя.
+var ErrAudienceMismatch = errors.New("the token audience is not accepted")
+
+// Полезная нагрузка JWT токена, содержащая зарегистрированные утверждения.
+type Claims interface {
+	// Получить зарегистрированные утверждения токена.
+	Registered() RegisteredClaims
+}
+
+// Зарегистрированные утверждения JWT токена (RFC 7519).
+type RegisteredClaims struct {
+	// Имя издателя токена.
+	Issuer string `json:"iss"`
+
+	// Получатели, для которых предназначен токен.
+	Audience Audience `json:"aud,omitempty"`
+
+	// Момент времени, когда токен был выдан в формате UNIX.
+	IssuedAt int64 `json:"iat"`
+
+	// Момент времени, до которого токен не считается действительным в формате UNIX.
+	NotBefore int64 `json:"nbf,omitempty"`
+
+	// Момент времени, до которого токен считается действительным в формате UNIX.
+	ExpirationTime int64 `json:"exp"`
+}
+
+// Получить зарегистрированные утверждения токена.
+func (s RegisteredClaims) Registered() RegisteredClaims {
+	return s
+}
+
+// Получатели, для которых предназначен токен.
+//
+// По RFC 7519 утверждение `aud` может быть как строкой, так и массивом строк.
+type Audience []string
+
+// Закодировать получателей: единственный получатель кодируется строкой.
+func (s Audience) MarshalJSON() ([]byte, error) {
+	if len(s) == 1 {
+		return json.Marshal(s[0])
+	}
+
+	return json.Marshal([]string(s))
+}
+
+// Декодировать получателей из строки или массива строк.
+func (s *Audience) UnmarshalJSON(value []byte) error {
+	var single string
+	err := json.Unmarshal(value, &single)
+	if err == nil {
+		*s = Audience{single}
+		return nil
+	}
+
+	var multiple []string
+	err = json.Unmarshal(value, &multiple)
+	if err != nil {
+		return err
+	}
+
+	*s = multiple
+
+	return nil
+}
+
+// Средство проверки зарегистрированных утверждений JWT токенов.
+type Validator struct {
+	// Имя издателя, которому доверяет получатель. Если не указано, издатель не проверяется.
+	Issuer string
+
+	// Допустимые получатели. Токен принимается, если в нем указан хотя бы один из них. Если не указаны, получатели не проверяются.
+	Audience []string
+
+	// Допустимое расхождение часов издателя и получателя.
+	Skew time.Duration
+
+	// Источник текущего времени. Если не указан, используется `time.Now`.
+	Clock func() time.Time
+
+	// Не проверять срок действия токена.
+	IgnoreExpiration bool
+}
+
+// Проверить зарегистрированные утверждения токена.
+func (s Validator) Validate(claims Claims) error {
+	registered := claims.Registered()
+	now := s.now()
+
+	if !s.IgnoreExpiration {
+		if registered.ExpirationTime == 0 {
+			return fmt.Errorf("%w: the token has no expiration time", ErrExpired)
+		}
+
+		if !now.Before(time.Unix(registered.ExpirationTime, 0).Add(s.Skew)) {
+			return ErrExpired
+		}
+	}
+
+	if registered.NotBefore != 0 && now.Add(s.Skew).Before(time.Unix(registered.NotBefore, 0)) {
+		return ErrNotYetValid
+	}
+
+	if registered.IssuedAt != 0 && now.Add(s.Skew).Before(time.Unix(registered.IssuedAt, 0)) {
+		return fmt.Errorf("%w: the token is issued in the future", ErrNotYetValid)
+	}
+
+	if s.Issuer != "" && registered.Issuer != s.Issuer {
+		return fmt.Errorf("%w: %q", ErrIssuerMismatch, registered.Issuer)
+	}
+
+	if len(s.Audience) > 0 && !slices.ContainsFunc(registered.Audience, s.acceptsAudience) {
+		return fmt.Errorf("%w: %v", ErrAudienceMismatch, []string(registered.Audience))
+	}
+
+	return nil
+}
+
+func (s Validator) acceptsAudience(audience string) bool {
+	return slices.Contains(s.Audience, audience)
+}
+
+func (s Validator) now() time.Time {
+	if s.Clock == nil {
+		return time.Now()
+	}
+
+	return s.Clock()
+}
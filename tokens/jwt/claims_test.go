@@ -0,0 +1,110 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	skew := 30 * time.Second
+
+	validator := Validator{
+		Issuer:   "issuer",
+		Audience: []string{"service", "other-service"},
+		Skew:     skew,
+		Clock:    func() time.Time { return now },
+	}
+
+	valid := RegisteredClaims{
+		Issuer:         "issuer",
+		Audience:       Audience{"service"},
+		IssuedAt:       now.Unix(),
+		ExpirationTime: now.Add(time.Minute).Unix(),
+	}
+
+	cases := []struct {
+		name   string
+		modify func(*RegisteredClaims)
+		want   error
+	}{
+		{"valid", func(c *RegisteredClaims) {}, nil},
+		{"exactly at exp", func(c *RegisteredClaims) { c.ExpirationTime = now.Unix() }, nil},
+		{"expired just within skew", func(c *RegisteredClaims) { c.ExpirationTime = now.Add(-skew + time.Second).Unix() }, nil},
+		{"exactly at exp plus skew", func(c *RegisteredClaims) { c.ExpirationTime = now.Add(-skew).Unix() }, ErrExpired},
+		{"expired beyond skew", func(c *RegisteredClaims) { c.ExpirationTime = now.Add(-time.Hour).Unix() }, ErrExpired},
+		{"no exp", func(c *RegisteredClaims) { c.ExpirationTime = 0 }, ErrExpired},
+		{"nbf within skew", func(c *RegisteredClaims) { c.NotBefore = now.Add(skew).Unix() }, nil},
+		{"nbf in the future", func(c *RegisteredClaims) { c.NotBefore = now.Add(skew + time.Second).Unix() }, ErrNotYetValid},
+		{"iat within skew", func(c *RegisteredClaims) { c.IssuedAt = now.Add(skew).Unix() }, nil},
+		{"iat in the future", func(c *RegisteredClaims) { c.IssuedAt = now.Add(skew + time.Second).Unix() }, ErrNotYetValid},
+		{"other issuer", func(c *RegisteredClaims) { c.Issuer = "other" }, ErrIssuerMismatch},
+		{"no issuer", func(c *RegisteredClaims) { c.Issuer = "" }, ErrIssuerMismatch},
+		{"other audience", func(c *RegisteredClaims) { c.Audience = Audience{"client"} }, ErrAudienceMismatch},
+		{"no audience", func(c *RegisteredClaims) { c.Audience = nil }, ErrAudienceMismatch},
+		{"one of several audiences", func(c *RegisteredClaims) { c.Audience = Audience{"client", "other-service"} }, nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			claims := valid
+			c.modify(&claims)
+
+			err := validator.Validate(claims)
+			if !errors.Is(err, c.want) {
+				t.Fatalf("Validate() = %v, want %v", err, c.want)
+			}
+		})
+	}
+}
+
+func TestValidateIgnoresExpirationAndUnconfiguredChecks(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	claims := RegisteredClaims{Issuer: "any", ExpirationTime: now.Add(-time.Hour).Unix()}
+
+	err := Validator{Clock: func() time.Time { return now }, IgnoreExpiration: true}.Validate(claims)
+	if err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+}
+
+func TestAudienceJson(t *testing.T) {
+	cases := map[string]Audience{
+		`"service"`:            {"service"},
+		`["service"]`:          {"service"},
+		`["service","client"]`: {"service", "client"},
+	}
+
+	for value, want := range cases {
+		var claims RegisteredClaims
+
+		err := json.Unmarshal([]byte(`{"aud": `+value+`}`), &claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !slices.Equal(claims.Audience, want) {
+			t.Errorf("aud %s decoded as %v, want %v", value, claims.Audience, want)
+		}
+
+		validator := Validator{Audience: []string{"service"}, IgnoreExpiration: true}
+
+		err = validator.Validate(claims)
+		if err != nil {
+			t.Errorf("Validate() with aud %s = %v", value, err)
+		}
+	}
+
+	encoded, err := json.Marshal(Audience{"service"})
+	if err != nil || string(encoded) != `"service"` {
+		t.Errorf("json.Marshal(Audience{service}) = %s, %v", encoded, err)
+	}
+
+	encoded, err = json.Marshal(Audience{"service", "client"})
+	if err != nil || string(encoded) != `["service","client"]` {
+		t.Errorf("json.Marshal(Audience{service, client}) = %s, %v", encoded, err)
+	}
+}
@@ -0,0 +1,140 @@
+package jwt
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Набор ключей, которыми издатель подписывает токены и проверяет их подпись.
+type SigningKeys interface {
+	KeySource
+
+	// Получить текущий ключ, которым подписываются токены.
+	Current() (Key, error)
+
+	// Получить ключи, которые принимаются при проверке подписи.
+	VerificationKeys() []Key
+}
+
+// Вспомогательное средство для издания JWT токенов с полезной нагрузкой указанного типа.
+//
+// Чтобы добавить новый вид токенов, достаточно описать тип полезной нагрузки, встраивающий
+// `RegisteredClaims`, и настроить для него издателя.
+type Issuer[T Claims] struct {
+	// Имя издателя токена.
+	Name string
+
+	// Время жизни токена.
+	Lifetime time.Duration
+
+	// Получатели, для которых предназначены выдаваемые токены.
+	Audience []string
+
+	// Набор ключей, с помощью которых подписываются токены и проверяется их подпись.
+	Keys SigningKeys
+
+	// Алгоритмы подписи, с которыми принимаются токены.
+	Algorithms []string
+
+	// Средство проверки зарегистрированных утверждений декодируемых токенов.
+	Validator Validator
+
+	// Средство шифрования токенов. Если указано, подписанные токены дополнительно шифруются в формате JWE.
+	Encrypter *Encrypter
+}
+
+// Получить зарегистрированные утверждения для токена, выдаваемого в текущий момент времени.
+func (s Issuer[T]) Registered() RegisteredClaims {
+	now := time.Now()
+
+	return RegisteredClaims{
+		Issuer:         s.Name,
+		Audience:       s.Audience,
+		IssuedAt:       now.Unix(),
+		ExpirationTime: now.Add(s.Lifetime).Unix(),
+	}
+}
+
+// Выдать новый токен с указанной полезной нагрузкой. Алгоритм и идентификатор ключа указываются в заголовке при кодировании.
+func (s Issuer[T]) New(payload T) Jwt[T] {
+	return Jwt[T]{
+		Header: Header{
+			Type: "JWT",
+		},
+		Payload: payload,
+	}
+}
+
+// Закодировать указанный токен с помощью текущего ключа издателя.
+func (s Issuer[T]) Encode(token Jwt[T]) (string, error) {
+	key, err := s.Keys.Current()
+	if err != nil {
+		return "", err
+	}
+
+	token.Header.Algorythm = key.Algorithm
+	token.Header.KeyId = key.Id
+
+	encodedToken, err := token.Encoded(key)
+	if err != nil {
+		return "", err
+	}
+
+	if s.Encrypter == nil {
+		return encodedToken, nil
+	}
+
+	return s.Encrypter.Encrypt([]byte(encodedToken), "JWT")
+}
+
+// Декодировать указанный закодированный токен, проверив его заголовок, подпись и зарегистрированные утверждения.
+func (s Issuer[T]) Decode(encodedToken string) (*Jwt[T], error) {
+	return s.decode(encodedToken, s.Validator)
+}
+
+// Декодировать указанный закодированный токен, не проверяя срок его действия.
+func (s Issuer[T]) DecodeExpired(encodedToken string) (*Jwt[T], error) {
+	validator := s.Validator
+	validator.IgnoreExpiration = true
+
+	return s.decode(encodedToken, validator)
+}
+
+func (s Issuer[T]) decode(encodedToken string, validator Validator) (*Jwt[T], error) {
+	signedToken, err := s.decrypt(encodedToken)
+	if err != nil {
+		return nil, err
+	}
+
+	decodedToken, err := Parse[T](signedToken, s.Keys, s.Algorithms)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validator.Validate(decodedToken.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodedToken, nil
+}
+
+// Расшифровать токен в формате JWE. Токены без шифрования возвращаются без изменений,
+// чтобы после включения шифрования продолжали приниматься ранее выданные токены.
+func (s Issuer[T]) decrypt(encodedToken string) (string, error) {
+	if strings.Count(encodedToken, ".") != 4 {
+		return encodedToken, nil
+	}
+
+	if s.Encrypter == nil {
+		return "", fmt.Errorf("%w: the issuer does not accept encrypted tokens", ErrMalformed)
+	}
+
+	signedToken, err := s.Encrypter.Decrypt(encodedToken)
+	if err != nil {
+		return "", err
+	}
+
+	return string(signedToken), nil
+}
@@ -0,0 +1,266 @@
+package jwt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Токен не удалось расшифровать.
+var ErrDecryption = errors.New("the token cannot be decrypted")
+
+// Размер ключа для алгоритмов A256GCM и A256KW в байтах.
+const ENCRYPTION_KEY_SIZE = 32
+
+// Заголовок зашифрованного токена в формате JWE.
+type EncryptionHeader struct {
+	// Алгоритм управления ключом шифрования содержимого.
+	Algorithm string `json:"alg"`
+
+	// Алгоритм шифрования содержимого.
+	Encryption string `json:"enc"`
+
+	// Тип зашифрованного содержимого.
+	ContentType string `json:"cty,omitempty"`
+
+	// Параметры заголовка, которые получатель обязан понимать и обрабатывать.
+	Critical []string `json:"crit,omitempty"`
+}
+
+// Средство шифрования токенов в формате JWE (RFC 7516) с компактной сериализацией.
+//
+// Содержимое шифруется алгоритмом A256GCM. Ключ шифрования содержимого либо совпадает с ключом
+// средства (алгоритм `dir`), либо генерируется для каждого токена и оборачивается ключом средства (алгоритм `A256KW`).
+type Encrypter struct {
+	// Алгоритм управления ключом: `dir` или `A256KW`.
+	Algorithm string
+
+	// Симметричный ключ длиной 32 байта.
+	Key []byte
+}
+
+// Зашифровать указанное содержимое указанного типа.
+func (s Encrypter) Encrypt(plaintext []byte, contentType string) (string, error) {
+	header, err := marshalAndEncode(EncryptionHeader{
+		Algorithm:   s.Algorithm,
+		Encryption:  "A256GCM",
+		ContentType: contentType,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	contentKey, encryptedKey, err := s.newContentKey()
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newGcm(contentKey)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nil, nonce, plaintext, []byte(header))
+	ciphertext := sealed[:len(sealed)-aead.Overhead()]
+	tag := sealed[len(sealed)-aead.Overhead():]
+
+	return strings.Join([]string{header, encode(encryptedKey), encode(nonce), encode(ciphertext), encode(tag)}, "."), nil
+}
+
+// Расшифровать указанный токен и получить его содержимое.
+func (s Encrypter) Decrypt(encryptedToken string) ([]byte, error) {
+	parts := strings.Split(encryptedToken, ".")
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("%w: the number of encrypted token parts is not equal to 5", ErrMalformed)
+	}
+
+	header, err := Decode[EncryptionHeader](parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	if header.Algorithm != s.Algorithm || header.Encryption != "A256GCM" || header.Critical != nil {
+		return nil, fmt.Errorf("%w: the encryption %s/%s is not allowed", ErrAlgMismatch, header.Algorithm, header.Encryption)
+	}
+
+	decodedParts := make([][]byte, 4)
+	for i := range decodedParts {
+		decodedParts[i], err = decode(parts[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+		}
+	}
+
+	contentKey, err := s.contentKey(decodedParts[0])
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newGcm(contentKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(decodedParts[1]) != aead.NonceSize() || len(decodedParts[3]) != aead.Overhead() {
+		return nil, fmt.Errorf("%w: the nonce or the tag has invalid length", ErrMalformed)
+	}
+
+	plaintext, err := aead.Open(nil, decodedParts[1], append(decodedParts[2], decodedParts[3]...), []byte(parts[0]))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecryption, err)
+	}
+
+	return plaintext, nil
+}
+
+// Получить ключ шифрования содержимого и его представление для передачи в токене.
+func (s Encrypter) newContentKey() ([]byte, []byte, error) {
+	err := s.check()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if s.Algorithm == "dir" {
+		return s.Key, []byte{}, nil
+	}
+
+	contentKey := make([]byte, ENCRYPTION_KEY_SIZE)
+	_, err = rand.Read(contentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encryptedKey, err := wrapKey(s.Key, contentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return contentKey, encryptedKey, nil
+}
+
+// Получить ключ шифрования содержимого из его представления в токене.
+func (s Encrypter) contentKey(encryptedKey []byte) ([]byte, error) {
+	err := s.check()
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Algorithm == "dir" {
+		if len(encryptedKey) != 0 {
+			return nil, fmt.Errorf("%w: the encrypted key must be empty for the algorithm dir", ErrMalformed)
+		}
+
+		return s.Key, nil
+	}
+
+	contentKey, err := unwrapKey(s.Key, encryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecryption, err)
+	}
+
+	if len(contentKey) != ENCRYPTION_KEY_SIZE {
+		return nil, fmt.Errorf("%w: the content key has invalid length", ErrDecryption)
+	}
+
+	return contentKey, nil
+}
+
+func (s Encrypter) check() error {
+	if s.Algorithm != "dir" && s.Algorithm != "A256KW" {
+		return fmt.Errorf("the key management algorithm %s is not supported", s.Algorithm)
+	}
+
+	if len(s.Key) != ENCRYPTION_KEY_SIZE {
+		return fmt.Errorf("the encryption key length %d is not equal to %d", len(s.Key), ENCRYPTION_KEY_SIZE)
+	}
+
+	return nil
+}
+
+func newGcm(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// Начальное значение алгоритма обертывания ключа (RFC 3394).
+var keyWrapIv = []byte{0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6}
+
+// Обернуть ключ алгоритмом AES Key Wrap (RFC 3394).
+func wrapKey(wrappingKey []byte, key []byte) ([]byte, error) {
+	if len(key)%8 != 0 || len(key) < 16 {
+		return nil, fmt.Errorf("the key length %d is not suitable for wrapping", len(key))
+	}
+
+	block, err := aes.NewCipher(wrappingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(key) / 8
+	result := make([]byte, len(key)+8)
+	copy(result, keyWrapIv)
+	copy(result[8:], key)
+
+	buffer := make([]byte, 16)
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(buffer, result[:8])
+			copy(buffer[8:], result[8*i:8*i+8])
+			block.Encrypt(buffer, buffer)
+
+			binary.BigEndian.PutUint64(result[:8], binary.BigEndian.Uint64(buffer[:8])^uint64(n*j+i))
+			copy(result[8*i:8*i+8], buffer[8:])
+		}
+	}
+
+	return result, nil
+}
+
+// Развернуть ключ, обернутый алгоритмом AES Key Wrap (RFC 3394).
+func unwrapKey(wrappingKey []byte, wrappedKey []byte) ([]byte, error) {
+	if len(wrappedKey)%8 != 0 || len(wrappedKey) < 24 {
+		return nil, fmt.Errorf("the wrapped key length %d is not valid", len(wrappedKey))
+	}
+
+	block, err := aes.NewCipher(wrappingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(wrappedKey)/8 - 1
+	result := make([]byte, len(wrappedKey))
+	copy(result, wrappedKey)
+
+	buffer := make([]byte, 16)
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			binary.BigEndian.PutUint64(buffer[:8], binary.BigEndian.Uint64(result[:8])^uint64(n*j+i))
+			copy(buffer[8:], result[8*i:8*i+8])
+			block.Decrypt(buffer, buffer)
+
+			copy(result[:8], buffer[:8])
+			copy(result[8*i:8*i+8], buffer[8:])
+		}
+	}
+
+	if subtle.ConstantTimeCompare(result[:8], keyWrapIv) != 1 {
+		return nil, fmt.Errorf("the wrapped key integrity check failed")
+	}
+
+	return result[8:], nil
+}
@@ -0,0 +1,109 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// Тестовые векторы RFC 3394, раздел 4.
+var keyWrapVectors = []struct {
+	name         string
+	wrappingKey  string
+	key          string
+	expectedWrap string
+}{
+	{
+		name:         "4.1 128 bits of key data with a 128-bit KEK",
+		wrappingKey:  "000102030405060708090A0B0C0D0E0F",
+		key:          "00112233445566778899AABBCCDDEEFF",
+		expectedWrap: "1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5",
+	},
+	{
+		name:         "4.3 128 bits of key data with a 256-bit KEK",
+		wrappingKey:  "000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F",
+		key:          "00112233445566778899AABBCCDDEEFF",
+		expectedWrap: "64E8C3F9CE0F5BA263E9777905818A2A93C8191E7D6E8AE7",
+	},
+	{
+		name:         "4.6 256 bits of key data with a 256-bit KEK",
+		wrappingKey:  "000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F",
+		key:          "00112233445566778899AABBCCDDEEFF000102030405060708090A0B0C0D0E0F",
+		expectedWrap: "28C9F404C4B810F4CBCCB35CFB87F8263F5786E2D80ED326CBC7F0E71A99F43BFB988B9B7A02DD21",
+	},
+}
+
+func TestWrapKey(t *testing.T) {
+	for _, vector := range keyWrapVectors {
+		t.Run(vector.name, func(t *testing.T) {
+			wrapped, err := wrapKey(mustDecodeHex(t, vector.wrappingKey), mustDecodeHex(t, vector.key))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(wrapped, mustDecodeHex(t, vector.expectedWrap)) {
+				t.Fatalf("wrapKey() = %X, want %s", wrapped, vector.expectedWrap)
+			}
+		})
+	}
+}
+
+func TestUnwrapKey(t *testing.T) {
+	for _, vector := range keyWrapVectors {
+		t.Run(vector.name, func(t *testing.T) {
+			unwrapped, err := unwrapKey(mustDecodeHex(t, vector.wrappingKey), mustDecodeHex(t, vector.expectedWrap))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(unwrapped, mustDecodeHex(t, vector.key)) {
+				t.Fatalf("unwrapKey() = %X, want %s", unwrapped, vector.key)
+			}
+		})
+	}
+}
+
+func TestUnwrapKeyRejectsTamperedKey(t *testing.T) {
+	vector := keyWrapVectors[0]
+
+	wrapped := mustDecodeHex(t, vector.expectedWrap)
+	wrapped[len(wrapped)-1] ^= 1
+
+	_, err := unwrapKey(mustDecodeHex(t, vector.wrappingKey), wrapped)
+	if err == nil {
+		t.Fatal("unwrapKey() accepted a tampered key")
+	}
+}
+
+func TestEncrypterRoundTrip(t *testing.T) {
+	for _, algorithm := range []string{"dir", "A256KW"} {
+		t.Run(algorithm, func(t *testing.T) {
+			encrypter := Encrypter{Algorithm: algorithm, Key: bytes.Repeat([]byte{7}, ENCRYPTION_KEY_SIZE)}
+
+			encrypted, err := encrypter.Encrypt([]byte("payload"), "JWT")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			decrypted, err := encrypter.Decrypt(encrypted)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(decrypted) != "payload" {
+				t.Fatalf("Decrypt() = %q, want %q", decrypted, "payload")
+			}
+		})
+	}
+}
+
+func mustDecodeHex(t *testing.T, value string) []byte {
+	t.Helper()
+
+	result, err := hex.DecodeString(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
@@ -0,0 +1,285 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// Ключ в формате JWK (RFC 7517).
+type Jwk struct {
+	// Тип ключа.
+	KeyType string `json:"kty"`
+
+	// Идентификатор ключа.
+	KeyId string `json:"kid,omitempty"`
+
+	// Алгоритм, для которого предназначен ключ.
+	Algorithm string `json:"alg,omitempty"`
+
+	// Назначение ключа.
+	Use string `json:"use,omitempty"`
+
+	// Кривая для ключей типа EC и OKP.
+	Curve string `json:"crv,omitempty"`
+
+	// Координата X для ключей типа EC или открытый ключ для ключей типа OKP.
+	X string `json:"x,omitempty"`
+
+	// Координата Y для ключей типа EC.
+	Y string `json:"y,omitempty"`
+
+	// Модуль для ключей типа RSA.
+	Modulus string `json:"n,omitempty"`
+
+	// Открытая экспонента для ключей типа RSA.
+	Exponent string `json:"e,omitempty"`
+}
+
+// Набор ключей в формате JWK Set (RFC 7517).
+type JwkSet struct {
+	// Ключи набора.
+	Keys []Jwk `json:"keys"`
+}
+
+// Создать набор из открытых частей указанных ключей. Ключи алгоритмов семейства HMAC в набор не попадают.
+func NewJwkSet(keys ...Key) JwkSet {
+	result := JwkSet{Keys: []Jwk{}}
+
+	for _, key := range keys {
+		jwk, err := key.Jwk()
+		if err != nil {
+			continue
+		}
+
+		result.Keys = append(result.Keys, jwk)
+	}
+
+	return result
+}
+
+// Разобрать набор ключей в формате JWK Set и получить ключи для проверки подписи.
+func ParseJwkSet(value []byte) ([]Key, error) {
+	var set JwkSet
+	err := json.Unmarshal(value, &set)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Key, 0, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.Key()
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, key)
+	}
+
+	return result, nil
+}
+
+// Получить открытую часть ключа в формате JWK.
+func (s Key) Jwk() (Jwk, error) {
+	result, err := s.publicJwk()
+	if err != nil {
+		return Jwk{}, err
+	}
+
+	result.KeyId = s.Id
+	if result.KeyId == "" {
+		result.KeyId, err = result.Thumbprint()
+		if err != nil {
+			return Jwk{}, err
+		}
+	}
+
+	result.Algorithm = s.Algorithm
+	result.Use = "sig"
+
+	return result, nil
+}
+
+// Получить ключ для проверки подписи из ключа в формате JWK.
+func (s Jwk) Key() (Key, error) {
+	if s.Algorithm == "" {
+		return Key{}, fmt.Errorf("the JWK %s has no algorithm", s.KeyId)
+	}
+
+	description, err := findAlgorithm(s.Algorithm)
+	if err != nil {
+		return Key{}, err
+	}
+
+	result := Key{Id: s.KeyId, Algorithm: s.Algorithm}
+
+	switch s.KeyType {
+	case "RSA":
+		result.Public, err = s.rsaPublicKey()
+	case "EC":
+		result.Public, err = s.ecdsaPublicKey()
+	case "OKP":
+		result.Public, err = s.ed25519PublicKey()
+	default:
+		err = fmt.Errorf("the JWK type %s is not supported", s.KeyType)
+	}
+
+	if err != nil {
+		return Key{}, err
+	}
+
+	err = result.check(description)
+	if err != nil {
+		return Key{}, err
+	}
+
+	return result, nil
+}
+
+// Получить отпечаток ключа (RFC 7638).
+func (s Jwk) Thumbprint() (string, error) {
+	var members any
+
+	switch s.KeyType {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{s.Exponent, s.KeyType, s.Modulus}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{s.Curve, s.KeyType, s.X, s.Y}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{s.Curve, s.KeyType, s.X}
+	default:
+		return "", fmt.Errorf("the JWK type %s is not supported", s.KeyType)
+	}
+
+	afterMarshalling, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(afterMarshalling)
+
+	return encode(hash[:]), nil
+}
+
+func (s Key) publicJwk() (Jwk, error) {
+	switch public := s.Public.(type) {
+	case *rsa.PublicKey:
+		return Jwk{
+			KeyType:  "RSA",
+			Modulus:  encode(public.N.Bytes()),
+			Exponent: encode(big.NewInt(int64(public.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		size := curveSize(public.Curve)
+		x := make([]byte, size)
+		y := make([]byte, size)
+		public.X.FillBytes(x)
+		public.Y.FillBytes(y)
+
+		return Jwk{
+			KeyType: "EC",
+			Curve:   public.Curve.Params().Name,
+			X:       encode(x),
+			Y:       encode(y),
+		}, nil
+	case ed25519.PublicKey:
+		return Jwk{
+			KeyType: "OKP",
+			Curve:   "Ed25519",
+			X:       encode(public),
+		}, nil
+	}
+
+	return Jwk{}, fmt.Errorf("the key for the algorithm %s has no public part", s.Algorithm)
+}
+
+func (s Jwk) rsaPublicKey() (*rsa.PublicKey, error) {
+	modulus, err := decode(s.Modulus)
+	if err != nil {
+		return nil, err
+	}
+
+	exponent, err := decode(s.Exponent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(modulus),
+		E: int(new(big.Int).SetBytes(exponent).Int64()),
+	}, nil
+}
+
+func (s Jwk) ecdsaPublicKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+
+	switch s.Curve {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	default:
+		return nil, fmt.Errorf("the JWK curve %s is not supported", s.Curve)
+	}
+
+	x, err := decode(s.X)
+	if err != nil {
+		return nil, err
+	}
+
+	y, err := decode(s.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}
+
+	if !curve.IsOnCurve(result.X, result.Y) {
+		return nil, fmt.Errorf("the JWK point is not on the curve %s", s.Curve)
+	}
+
+	return result, nil
+}
+
+func (s Jwk) ed25519PublicKey() (ed25519.PublicKey, error) {
+	if s.Curve != "Ed25519" {
+		return nil, fmt.Errorf("the JWK curve %s is not supported", s.Curve)
+	}
+
+	x, err := decode(s.X)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(x) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("the JWK Ed25519 key has invalid length %d", len(x))
+	}
+
+	return ed25519.PublicKey(x), nil
+}
@@ -0,0 +1,72 @@
+package jwt
+
+import (
+	"testing"
+)
+
+func TestThumbprint(t *testing.T) {
+	// Пример из RFC 7638, раздел 3.1.
+	jwk := Jwk{
+		KeyType:   "RSA",
+		Modulus:   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		Exponent:  "AQAB",
+		Algorithm: "RS256",
+		KeyId:     "2011-04-29",
+	}
+
+	thumbprint, err := jwk.Thumbprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if thumbprint != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Fatalf("Thumbprint() = %s", thumbprint)
+	}
+}
+
+func TestJwkSetRoundTrip(t *testing.T) {
+	for _, algorithm := range []string{"RS256", "PS256", "ES256", "ES384", "EdDSA"} {
+		t.Run(algorithm, func(t *testing.T) {
+			key, err := GenerateKey(algorithm)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			jwk, err := key.Jwk()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if jwk.KeyId != key.Id {
+				t.Fatalf("Jwk().KeyId = %s, want %s", jwk.KeyId, key.Id)
+			}
+
+			public, err := jwk.Key()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			signature, err := key.Sign(algorithm, []byte("data"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = public.Verify(algorithm, []byte("data"), signature)
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestJwkSetSkipsHmacKeys(t *testing.T) {
+	key, err := GenerateKey("HS256")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set := NewJwkSet(key)
+	if len(set.Keys) != 0 {
+		t.Fatalf("NewJwkSet() published %d HMAC keys", len(set.Keys))
+	}
+}
@@ -3,7 +3,6 @@ package jwt
 import (
 	"encoding/base64"
 	"encoding/json"
-	"hash"
 	"strings"
 )
 
@@ -23,10 +22,16 @@ type Header struct {
 
 	// Тип токена.
 	Type string `json:"typ"`
+
+	// Идентификатор ключа, которым подписан токен.
+	KeyId string `json:"kid,omitempty"`
+
+	// Параметры заголовка, которые получатель обязан понимать и обрабатывать.
+	Critical []string `json:"crit,omitempty"`
 }
 
-// Закодировать JWT токен.
-func (s Jwt[T]) Encoded(hash hash.Hash) (string, error) {
+// Закодировать JWT токен, подписав его с помощью алгоритма, указанного в заголовке.
+func (s Jwt[T]) Encoded(signer Signer) (string, error) {
 	encodedHeader, err := marshalAndEncode(s.Header)
 	if err != nil {
 		return "", err
@@ -37,7 +42,7 @@ func (s Jwt[T]) Encoded(hash hash.Hash) (string, error) {
 		return "", err
 	}
 
-	signature, err := s.Signature(hash)
+	signature, err := s.Signature(signer)
 	if err != nil {
 		return "", err
 	}
@@ -45,8 +50,8 @@ func (s Jwt[T]) Encoded(hash hash.Hash) (string, error) {
 	return encodedHeader + "." + encodedPayload + "." + signature, nil
 }
 
-// Получить подпись JWT токена с помощью указанной функции.
-func (s Jwt[T]) Signature(hash hash.Hash) (string, error) {
+// Получить подпись JWT токена с помощью алгоритма, указанного в заголовке.
+func (s Jwt[T]) Signature(signer Signer) (string, error) {
 	encodedHeader, err := marshalAndEncode(s.Header)
 	if err != nil {
 		return "", err
@@ -57,15 +62,16 @@ func (s Jwt[T]) Signature(hash hash.Hash) (string, error) {
 		return "", err
 	}
 
-	hash.Write([]byte(encodedHeader + "." + encodedPayload))
-
-	var result = encode(hash.Sum(nil))
+	signature, err := signer.Sign(s.Header.Algorythm, []byte(encodedHeader+"."+encodedPayload))
+	if err != nil {
+		return "", err
+	}
 
-	return result, nil
+	return encode(signature), nil
 }
 
 func Decode[T any](value string) (*T, error) {
-	afterDecoding, err := base64.RawStdEncoding.DecodeString(value)
+	afterDecoding, err := decode(value)
 	if err != nil {
 		return nil, err
 	}
@@ -94,3 +100,7 @@ func encode(value []byte) string {
 
 	return afterTrimming
 }
+
+func decode(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(value)
+}
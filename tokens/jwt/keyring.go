@@ -0,0 +1,215 @@
+package jwt
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Набор ключей издателя токенов, поддерживающий ротацию.
+//
+// Токены подписываются самым новым ключом набора. Ключи, выведенные из использования при ротации,
+// продолжают приниматься при проверке подписи до окончания льготного периода.
+type Keyring struct {
+	// Время, в течение которого выведенный из использования ключ продолжает приниматься при проверке подписи.
+	GracePeriod time.Duration
+
+	mutex   sync.RWMutex
+	entries []keyringEntry
+}
+
+// Ключ в наборе.
+type keyringEntry struct {
+	// Ключ.
+	key Key
+
+	// Момент времени, когда ключ был выведен из использования. Нулевое значение для текущего ключа.
+	retiredAt time.Time
+}
+
+// Описание ключа в конфигурации набора ключей.
+type KeyringEntryConfig struct {
+	// Идентификатор ключа, передаваемый в заголовке `kid` токена. Если не указан, вычисляется по ключу.
+	Id string `json:"kid,omitempty"`
+
+	// Алгоритм подписи, для которого предназначен ключ.
+	Algorithm string `json:"alg"`
+
+	// Секрет для алгоритмов семейства HMAC или ключ в формате PEM для остальных алгоритмов, как в `NewKey`.
+	Key string `json:"key"`
+
+	// Момент времени, когда ключ был выведен из использования. Не указывается для текущего ключа и обязателен для остальных.
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+}
+
+// Конфигурация набора ключей в формате JSON.
+type KeyringConfig struct {
+	// Ключи набора. Первый ключ является текущим, остальные выведены из использования.
+	Keys []KeyringEntryConfig `json:"keys"`
+}
+
+// Создать набор ключей. Последний из указанных ключей становится текущим.
+func NewKeyring(gracePeriod time.Duration, keys ...Key) *Keyring {
+	result := &Keyring{GracePeriod: gracePeriod}
+
+	for _, key := range keys {
+		result.Rotate(key)
+	}
+
+	return result
+}
+
+// Сделать указанный ключ текущим, выведя предыдущий текущий ключ из использования.
+func (s *Keyring) Rotate(key Key) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+
+	if len(s.entries) > 0 {
+		s.entries[len(s.entries)-1].retiredAt = now
+	}
+
+	s.entries = append(s.activeEntries(now), keyringEntry{key: key})
+}
+
+// Заменить ключи набора ключами из конфигурации в формате JSON (`KeyringConfig`).
+//
+// Момент вывода ключа из использования берется из конфигурации, поэтому экземпляры сервиса с одинаковой
+// конфигурацией принимают одни и те же ключи, в том числе после перезапуска. При ошибке набор не изменяется.
+func (s *Keyring) Load(value []byte) error {
+	var config KeyringConfig
+	err := json.Unmarshal(value, &config)
+	if err != nil {
+		return err
+	}
+
+	entries, err := config.entries()
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.entries = entries
+
+	return nil
+}
+
+// Получить текущий ключ, которым подписываются токены.
+func (s *Keyring) Current() (Key, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if len(s.entries) == 0 {
+		return Key{}, fmt.Errorf("the keyring is empty")
+	}
+
+	return s.entries[len(s.entries)-1].key, nil
+}
+
+// Найти ключ для проверки подписи по его идентификатору.
+//
+// Если идентификатор не указан, возвращается текущий ключ.
+func (s *Keyring) Find(keyId string) (Key, error) {
+	if keyId == "" {
+		return s.Current()
+	}
+
+	for _, key := range s.VerificationKeys() {
+		if key.Id == keyId {
+			return key, nil
+		}
+	}
+
+	return Key{}, fmt.Errorf("the key %s is not found in the keyring", keyId)
+}
+
+// Получить ключи, которые принимаются при проверке подписи: текущий ключ и ключи, льготный период которых не закончился.
+func (s *Keyring) VerificationKeys() []Key {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	entries := s.activeEntries(time.Now())
+
+	result := make([]Key, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		result = append(result, entries[i].key)
+	}
+
+	return result
+}
+
+// Получить алгоритмы ключей, которые принимаются при проверке подписи.
+func (s *Keyring) Algorithms() []string {
+	result := []string{}
+
+	for _, key := range s.VerificationKeys() {
+		if !slices.Contains(result, key.Algorithm) {
+			result = append(result, key.Algorithm)
+		}
+	}
+
+	return result
+}
+
+func (s *Keyring) activeEntries(now time.Time) []keyringEntry {
+	result := make([]keyringEntry, 0, len(s.entries))
+
+	for _, entry := range s.entries {
+		if entry.retiredAt.IsZero() || now.Before(entry.retiredAt.Add(s.GracePeriod)) {
+			result = append(result, entry)
+		}
+	}
+
+	return result
+}
+
+// Получить ключи набора в порядке от самого старого к текущему.
+func (s KeyringConfig) entries() ([]keyringEntry, error) {
+	if len(s.Keys) == 0 {
+		return nil, fmt.Errorf("the keyring config has no keys")
+	}
+
+	result := make([]keyringEntry, 0, len(s.Keys))
+	ids := map[string]bool{}
+
+	for i, config := range s.Keys {
+		if i == 0 && config.RetiredAt != nil {
+			return nil, fmt.Errorf("the current key %s cannot be retired", config.Id)
+		}
+
+		if i > 0 && config.RetiredAt == nil {
+			return nil, fmt.Errorf("the key %s is not current and has no retirement time", config.Id)
+		}
+
+		key, err := NewKey(config.Algorithm, config.Key)
+		if err != nil {
+			return nil, fmt.Errorf("the key %s: %w", config.Id, err)
+		}
+
+		if config.Id != "" {
+			key.Id = config.Id
+		}
+
+		if ids[key.Id] {
+			return nil, fmt.Errorf("the key id %s is not unique", key.Id)
+		}
+
+		ids[key.Id] = true
+
+		entry := keyringEntry{key: key}
+		if config.RetiredAt != nil {
+			entry.retiredAt = *config.RetiredAt
+		}
+
+		result = append(result, entry)
+	}
+
+	slices.Reverse(result)
+
+	return result, nil
+}
@@ -0,0 +1,97 @@
+package jwt
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeyringLoad(t *testing.T) {
+	now := time.Now().UTC()
+	secret := strings.Repeat("s", 32)
+
+	config := fmt.Sprintf(`{"keys": [
+		{"kid": "current", "alg": "HS256", "key": "%s-current"},
+		{"kid": "recent", "alg": "HS256", "key": "%s-recent", "retired_at": %q},
+		{"kid": "expired", "alg": "HS256", "key": "%s-expired", "retired_at": %q}
+	]}`, secret, secret, now.Add(-time.Minute).Format(time.RFC3339), secret, now.Add(-time.Hour).Format(time.RFC3339))
+
+	keyring := &Keyring{GracePeriod: 15 * time.Minute}
+
+	err := keyring.Load([]byte(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := keyring.Current()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if current.Id != "current" {
+		t.Fatalf("Current().Id = %s, want current", current.Id)
+	}
+
+	_, err = keyring.Find("recent")
+	if err != nil {
+		t.Fatalf("Find() rejected the key retired within the grace period: %v", err)
+	}
+
+	_, err = keyring.Find("expired")
+	if err == nil {
+		t.Fatal("Find() accepted the key retired before the grace period")
+	}
+}
+
+func TestKeyringLoadKeepsKeysOnError(t *testing.T) {
+	key, err := NewKey("HS256", strings.Repeat("s", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyring := NewKeyring(time.Minute, key)
+
+	invalidConfigs := []string{
+		`{"keys": []}`,
+		`{"keys": [{"alg": "HS256", "key": "short"}]}`,
+		`{"keys": [{"alg": "HS256", "key": "` + strings.Repeat("a", 32) + `", "retired_at": "2020-01-01T00:00:00Z"}]}`,
+		`{"keys": [{"kid": "a", "alg": "HS256", "key": "` + strings.Repeat("a", 32) + `"}, {"kid": "b", "alg": "HS256", "key": "` + strings.Repeat("b", 32) + `"}]}`,
+		`{"keys": [{"kid": "a", "alg": "HS256", "key": "` + strings.Repeat("a", 32) + `"}, {"kid": "a", "alg": "HS256", "key": "` + strings.Repeat("b", 32) + `", "retired_at": "2020-01-01T00:00:00Z"}]}`,
+	}
+
+	for _, config := range invalidConfigs {
+		err = keyring.Load([]byte(config))
+		if err == nil {
+			t.Errorf("Load() accepted %s", config)
+		}
+	}
+
+	current, err := keyring.Current()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if current.Id != key.Id {
+		t.Fatal("Load() replaced the keys despite an error")
+	}
+}
+
+func TestKeyringAlgorithms(t *testing.T) {
+	hmacKey, err := GenerateKey("HS256")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecdsaKey, err := GenerateKey("ES256")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyring := NewKeyring(time.Minute, hmacKey, ecdsaKey)
+
+	algorithms := keyring.Algorithms()
+	if len(algorithms) != 2 || algorithms[0] != "ES256" || algorithms[1] != "HS256" {
+		t.Fatalf("Algorithms() = %v", algorithms)
+	}
+}
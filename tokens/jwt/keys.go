@@ -0,0 +1,424 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+// Средство подписи JWT токенов.
+type Signer interface {
+	// Подписать указанные данные с помощью указанного алгоритма.
+	Sign(algorithm string, data []byte) ([]byte, error)
+}
+
+// Средство проверки подписи JWT токенов.
+type Verifier interface {
+	// Проверить подпись указанных данных, выполненную с помощью указанного алгоритма.
+	Verify(algorithm string, data []byte, signature []byte) error
+}
+
+// Ключ для подписи и проверки подписи JWT токенов.
+type Key struct {
+	// Идентификатор ключа, передаваемый в заголовке `kid` токена.
+	Id string
+
+	// Алгоритм подписи, для которого предназначен ключ.
+	Algorithm string
+
+	// Секрет для алгоритмов семейства HMAC.
+	Secret []byte
+
+	// Закрытый ключ для асимметричных алгоритмов. Может отсутствовать у ключа, предназначенного только для проверки подписи.
+	Private crypto.Signer
+
+	// Открытый ключ для асимметричных алгоритмов.
+	Public crypto.PublicKey
+}
+
+// Минимальный размер ключа RSA в битах.
+const MIN_RSA_KEY_SIZE_IN_BITS = 2048
+
+// Семейство алгоритмов подписи.
+type family int
+
+const (
+	hmacFamily family = iota
+	rsaFamily
+	rsaPssFamily
+	ecdsaFamily
+	ed25519Family
+)
+
+// Описание алгоритма подписи.
+type scheme struct {
+	// Семейство алгоритма.
+	family family
+
+	// Хэш-функция, применяемая к подписываемым данным.
+	hash crypto.Hash
+
+	// Кривая для алгоритмов семейства ECDSA.
+	curve elliptic.Curve
+}
+
+// Поддерживаемые алгоритмы подписи.
+var algorithms = map[string]scheme{
+	"HS256": {family: hmacFamily, hash: crypto.SHA256},
+	"HS384": {family: hmacFamily, hash: crypto.SHA384},
+	"HS512": {family: hmacFamily, hash: crypto.SHA512},
+	"RS256": {family: rsaFamily, hash: crypto.SHA256},
+	"RS384": {family: rsaFamily, hash: crypto.SHA384},
+	"RS512": {family: rsaFamily, hash: crypto.SHA512},
+	"PS256": {family: rsaPssFamily, hash: crypto.SHA256},
+	"PS384": {family: rsaPssFamily, hash: crypto.SHA384},
+	"PS512": {family: rsaPssFamily, hash: crypto.SHA512},
+	"ES256": {family: ecdsaFamily, hash: crypto.SHA256, curve: elliptic.P256()},
+	"ES384": {family: ecdsaFamily, hash: crypto.SHA384, curve: elliptic.P384()},
+	"EdDSA": {family: ed25519Family},
+}
+
+// Создать ключ для указанного алгоритма.
+//
+// Для алгоритмов семейства HMAC значение используется как секрет, для остальных алгоритмов
+// значение должно содержать закрытый или открытый ключ в формате PEM.
+func NewKey(algorithm string, value string) (Key, error) {
+	description, err := findAlgorithm(algorithm)
+	if err != nil {
+		return Key{}, err
+	}
+
+	if description.family == hmacFamily {
+		key := Key{Algorithm: algorithm, Secret: []byte(value)}
+
+		err = key.check(description)
+		if err != nil {
+			return Key{}, err
+		}
+
+		key.Id = key.secretId(description)
+
+		return key, nil
+	}
+
+	block, _ := pem.Decode([]byte(value))
+	if block == nil {
+		return Key{}, fmt.Errorf("the key for the algorithm %s is not a PEM block", algorithm)
+	}
+
+	key, err := parsePemBlock(block)
+	if err != nil {
+		return Key{}, err
+	}
+
+	key.Algorithm = algorithm
+
+	err = key.check(description)
+	if err != nil {
+		return Key{}, err
+	}
+
+	jwk, err := key.publicJwk()
+	if err != nil {
+		return Key{}, err
+	}
+
+	key.Id, err = jwk.Thumbprint()
+	if err != nil {
+		return Key{}, err
+	}
+
+	return key, nil
+}
+
+// Сгенерировать новый ключ для указанного алгоритма.
+func GenerateKey(algorithm string) (Key, error) {
+	description, err := findAlgorithm(algorithm)
+	if err != nil {
+		return Key{}, err
+	}
+
+	var private crypto.Signer
+
+	switch description.family {
+	case hmacFamily:
+		secret := make([]byte, description.hash.Size())
+		_, err = rand.Read(secret)
+		if err != nil {
+			return Key{}, err
+		}
+
+		return NewKey(algorithm, string(secret))
+	case rsaFamily, rsaPssFamily:
+		private, err = rsa.GenerateKey(rand.Reader, MIN_RSA_KEY_SIZE_IN_BITS)
+	case ecdsaFamily:
+		private, err = ecdsa.GenerateKey(description.curve, rand.Reader)
+	case ed25519Family:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	}
+
+	if err != nil {
+		return Key{}, err
+	}
+
+	marshalled, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return Key{}, err
+	}
+
+	return NewKey(algorithm, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: marshalled})))
+}
+
+// Подписать указанные данные с помощью указанного алгоритма.
+func (s Key) Sign(algorithm string, data []byte) ([]byte, error) {
+	description, err := s.scheme(algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	if description.family == hmacFamily {
+		return s.mac(description, data), nil
+	}
+
+	if s.Private == nil {
+		return nil, fmt.Errorf("the key for the algorithm %s has no private part", algorithm)
+	}
+
+	switch description.family {
+	case rsaFamily:
+		return s.Private.Sign(rand.Reader, digest(description, data), description.hash)
+	case rsaPssFamily:
+		return s.Private.Sign(rand.Reader, digest(description, data), &rsa.PSSOptions{
+			SaltLength: rsa.PSSSaltLengthEqualsHash,
+			Hash:       description.hash,
+		})
+	case ecdsaFamily:
+		return s.signEcdsa(description, data)
+	case ed25519Family:
+		return s.Private.Sign(rand.Reader, data, crypto.Hash(0))
+	}
+
+	return nil, fmt.Errorf("the algorithm %s is not supported", algorithm)
+}
+
+// Проверить подпись указанных данных, выполненную с помощью указанного алгоритма.
+func (s Key) Verify(algorithm string, data []byte, signature []byte) error {
+	description, err := s.scheme(algorithm)
+	if err != nil {
+		return err
+	}
+
+	valid := false
+
+	switch description.family {
+	case hmacFamily:
+		valid = hmac.Equal(signature, s.mac(description, data))
+	case rsaFamily:
+		valid = rsa.VerifyPKCS1v15(s.Public.(*rsa.PublicKey), description.hash, digest(description, data), signature) == nil
+	case rsaPssFamily:
+		valid = rsa.VerifyPSS(s.Public.(*rsa.PublicKey), description.hash, digest(description, data), signature, &rsa.PSSOptions{
+			SaltLength: rsa.PSSSaltLengthAuto,
+			Hash:       description.hash,
+		}) == nil
+	case ecdsaFamily:
+		valid = s.verifyEcdsa(description, data, signature)
+	case ed25519Family:
+		valid = ed25519.Verify(s.Public.(ed25519.PublicKey), data, signature)
+	}
+
+	if !valid {
+		return fmt.Errorf("the signature is not valid for the algorithm %s", algorithm)
+	}
+
+	return nil
+}
+
+// Вычислить левую половину хэша указанного значения в кодировке Base64URL, как для утверждений `at_hash` и `c_hash` OpenID Connect.
+//
+// Хэш-функция определяется алгоритмом подписи токена, для EdDSA применяется SHA-512.
+func HalfHash(algorithm string, value string) (string, error) {
+	description, err := findAlgorithm(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	if description.family == ed25519Family {
+		description.hash = crypto.SHA512
+	}
+
+	hash := digest(description, []byte(value))
+
+	return encode(hash[:len(hash)/2]), nil
+}
+
+// Проверить, является ли алгоритм подписи симметричным (семейства HMAC).
+//
+// Подпись таких токенов проверяется тем же секретом, которым они подписываются, поэтому каждый, кто может проверить подпись, может и подделать токен.
+func IsSymmetric(algorithm string) bool {
+	description, err := findAlgorithm(algorithm)
+
+	return err == nil && description.family == hmacFamily
+}
+
+// Получить описание алгоритма, проверив, что ключ может быть использован с ним.
+func (s Key) scheme(algorithm string) (scheme, error) {
+	description, err := findAlgorithm(algorithm)
+	if err != nil {
+		return description, err
+	}
+
+	if s.Algorithm != algorithm {
+		return description, fmt.Errorf("the key for the algorithm %s cannot be used with the algorithm %s", s.Algorithm, algorithm)
+	}
+
+	return description, s.check(description)
+}
+
+// Проверить, что тип и размер ключа соответствуют алгоритму.
+//
+// Секрет алгоритма семейства HMAC должен быть не короче выхода хэш-функции, ключ RSA — не короче `MIN_RSA_KEY_SIZE_IN_BITS`.
+func (s Key) check(description scheme) error {
+	valid := false
+
+	switch description.family {
+	case hmacFamily:
+		if len(s.Secret) < description.hash.Size() {
+			return fmt.Errorf("the secret for the algorithm %s must be at least %d bytes long", s.Algorithm, description.hash.Size())
+		}
+
+		valid = true
+	case rsaFamily, rsaPssFamily:
+		public, ok := s.Public.(*rsa.PublicKey)
+		if ok && public.N.BitLen() < MIN_RSA_KEY_SIZE_IN_BITS {
+			return fmt.Errorf("the RSA key for the algorithm %s must be at least %d bits long", s.Algorithm, MIN_RSA_KEY_SIZE_IN_BITS)
+		}
+
+		valid = ok
+	case ecdsaFamily:
+		public, ok := s.Public.(*ecdsa.PublicKey)
+		valid = ok && public.Curve == description.curve
+	case ed25519Family:
+		_, valid = s.Public.(ed25519.PublicKey)
+	}
+
+	if !valid {
+		return fmt.Errorf("the key is not suitable for the algorithm %s", s.Algorithm)
+	}
+
+	return nil
+}
+
+// Получить идентификатор ключа алгоритма семейства HMAC, не раскрывающий секрет.
+func (s Key) secretId(description scheme) string {
+	return encode(s.mac(description, []byte("kid"))[:12])
+}
+
+func (s Key) mac(description scheme, data []byte) []byte {
+	mac := hmac.New(description.hash.New, s.Secret)
+	mac.Write(data)
+
+	return mac.Sum(nil)
+}
+
+// Подписать данные алгоритмом семейства ECDSA. Подпись представляется в виде R || S, как того требует RFC 7518.
+func (s Key) signEcdsa(description scheme, data []byte) ([]byte, error) {
+	private, ok := s.Private.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("the key is not suitable for the algorithm %s", s.Algorithm)
+	}
+
+	r, sign, err := ecdsa.Sign(rand.Reader, private, digest(description, data))
+	if err != nil {
+		return nil, err
+	}
+
+	size := curveSize(description.curve)
+	result := make([]byte, 2*size)
+	r.FillBytes(result[:size])
+	sign.FillBytes(result[size:])
+
+	return result, nil
+}
+
+func (s Key) verifyEcdsa(description scheme, data []byte, signature []byte) bool {
+	size := curveSize(description.curve)
+	if len(signature) != 2*size {
+		return false
+	}
+
+	r := new(big.Int).SetBytes(signature[:size])
+	sign := new(big.Int).SetBytes(signature[size:])
+
+	return ecdsa.Verify(s.Public.(*ecdsa.PublicKey), digest(description, data), r, sign)
+}
+
+func findAlgorithm(name string) (scheme, error) {
+	description, ok := algorithms[name]
+	if !ok {
+		return description, fmt.Errorf("the algorithm %s is not supported", name)
+	}
+
+	return description, nil
+}
+
+func parsePemBlock(block *pem.Block) (Key, error) {
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return Key{}, err
+		}
+
+		signer, ok := private.(crypto.Signer)
+		if !ok {
+			return Key{}, fmt.Errorf("the private key of type %T is not supported", private)
+		}
+
+		return Key{Private: signer, Public: signer.Public()}, nil
+	case "RSA PRIVATE KEY":
+		private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return Key{}, err
+		}
+
+		return Key{Private: private, Public: private.Public()}, nil
+	case "EC PRIVATE KEY":
+		private, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return Key{}, err
+		}
+
+		return Key{Private: private, Public: private.Public()}, nil
+	case "PUBLIC KEY":
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return Key{}, err
+		}
+
+		return Key{Public: public}, nil
+	}
+
+	return Key{}, fmt.Errorf("the PEM block of type %s is not supported", block.Type)
+}
+
+func digest(description scheme, data []byte) []byte {
+	hash := description.hash.New()
+	hash.Write(data)
+
+	return hash.Sum(nil)
+}
+
+func curveSize(curve elliptic.Curve) int {
+	return (curve.Params().BitSize + 7) / 8
+}
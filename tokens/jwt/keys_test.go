@@ -0,0 +1,104 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	for algorithm := range algorithms {
+		t.Run(algorithm, func(t *testing.T) {
+			key, err := GenerateKey(algorithm)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			signature, err := key.Sign(algorithm, []byte("data"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = key.Verify(algorithm, []byte("data"), signature)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = key.Verify(algorithm, []byte("other data"), signature)
+			if err == nil {
+				t.Fatal("Verify() accepted a signature of other data")
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsOtherAlgorithm(t *testing.T) {
+	key, err := GenerateKey("RS256")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature, err := key.Sign("RS256", []byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = key.Verify("PS256", []byte("data"), signature)
+	if err == nil {
+		t.Fatal("Verify() accepted the key with another algorithm")
+	}
+}
+
+func TestNewKeyRejectsShortHmacSecret(t *testing.T) {
+	_, err := NewKey("HS512", "access")
+	if err == nil {
+		t.Fatal("NewKey() accepted a 6-byte secret for HS512")
+	}
+
+	_, err = NewKey("HS256", strings.Repeat("s", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewKeyRejectsShortRsaKey(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)})
+
+	_, err = NewKey("RS256", string(value))
+	if err == nil {
+		t.Fatal("NewKey() accepted a 1024-bit RSA key")
+	}
+}
+
+func TestHalfHash(t *testing.T) {
+	// Пример из OpenID Connect Core 1.0, приложение A.3.
+	hash, err := HalfHash("RS256", "jHkWEdUXMU1BwAsC4vtUsZwnNvTIxEl0z9K3vx5KF0Y")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hash != "77QmUPtjPfzWtF2AnpK9RQ" {
+		t.Fatalf("HalfHash() = %s", hash)
+	}
+}
+
+func TestIsSymmetric(t *testing.T) {
+	for algorithm := range algorithms {
+		want := strings.HasPrefix(algorithm, "HS")
+		if IsSymmetric(algorithm) != want {
+			t.Errorf("IsSymmetric(%s) = %t, want %t", algorithm, !want, want)
+		}
+	}
+
+	if IsSymmetric("none") {
+		t.Error("IsSymmetric() accepted an unknown algorithm")
+	}
+}
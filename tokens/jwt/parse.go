@@ -0,0 +1,94 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Токен имеет неверный формат.
+var ErrMalformed = errors.New("the token is malformed")
+
+// Подпись токена недействительна.
+var ErrBadSignature = errors.New("the token signature is not valid")
+
+// Алгоритм подписи токена не разрешен издателем или не соответствует ключу.
+var ErrAlgMismatch = errors.New("the token algorithm is not allowed")
+
+// Источник ключей для проверки подписи токенов.
+type KeySource interface {
+	// Найти ключ для проверки подписи по его идентификатору.
+	Find(keyId string) (Key, error)
+}
+
+// Декодировать закодированный JWT токен и проверить его заголовок и подпись.
+//
+// Токен принимается, только если алгоритм подписи из заголовка входит в список разрешенных
+// алгоритмов и совпадает с алгоритмом ключа, найденного по заголовку `kid`.
+func Parse[T any](encodedToken string, keys KeySource, algorithms []string) (*Jwt[T], error) {
+	parts := strings.Split(encodedToken, ".")
+	if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("%w: the number of token parts is not equal to 3", ErrMalformed)
+	}
+
+	header, err := Decode[Header](parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	err = validateHeader(*header, algorithms)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := keys.Find(header.KeyId)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBadSignature, err)
+	}
+
+	if key.Algorithm != header.Algorythm {
+		return nil, fmt.Errorf("%w: the key %s is intended for the algorithm %s", ErrAlgMismatch, key.Id, key.Algorithm)
+	}
+
+	signature, err := decode(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	err = key.Verify(header.Algorythm, []byte(parts[0]+"."+parts[1]), signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBadSignature, err)
+	}
+
+	payload, err := Decode[T](parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	return &Jwt[T]{
+		Header:  *header,
+		Payload: *payload,
+	}, nil
+}
+
+func validateHeader(header Header, algorithms []string) error {
+	if header.Type != "" && !strings.EqualFold(header.Type, "JWT") {
+		return fmt.Errorf("%w: the token type %s is not supported", ErrMalformed, header.Type)
+	}
+
+	if header.Critical != nil {
+		return fmt.Errorf("%w: the critical header parameters %v are not supported", ErrMalformed, header.Critical)
+	}
+
+	if !slices.Contains(algorithms, header.Algorythm) {
+		return fmt.Errorf("%w: %q", ErrAlgMismatch, header.Algorythm)
+	}
+
+	_, err := findAlgorithm(header.Algorythm)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrAlgMismatch, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package jwt
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testPayload struct {
+	Subject string `json:"sub"`
+}
+
+func signTestToken(t *testing.T, key Key, header Header) string {
+	token := Jwt[testPayload]{Header: header, Payload: testPayload{Subject: "user"}}
+
+	encodedToken, err := token.Encoded(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return encodedToken
+}
+
+func TestParse(t *testing.T) {
+	key, err := GenerateKey("ES256")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyring := NewKeyring(time.Minute, key)
+
+	encodedToken := signTestToken(t, key, Header{Algorythm: "ES256", Type: "JWT", KeyId: key.Id})
+
+	token, err := Parse[testPayload](encodedToken, keyring, []string{"ES256"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token.Payload.Subject != "user" || token.Header.KeyId != key.Id {
+		t.Fatalf("Parse() = %+v", token)
+	}
+}
+
+func TestParseRejectsInvalidTokens(t *testing.T) {
+	rsaKey, err := GenerateKey("RS256")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecdsaKey, err := GenerateKey("ES256")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherKey, err := GenerateKey("RS256")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyring := NewKeyring(time.Minute, ecdsaKey, rsaKey)
+	algorithms := []string{"RS256", "ES256"}
+
+	valid := signTestToken(t, rsaKey, Header{Algorythm: "RS256", Type: "JWT", KeyId: rsaKey.Id})
+	parts := strings.Split(valid, ".")
+
+	// Атака с подменой алгоритма: открытый ключ RSA, известный всем, используется как секрет HMAC.
+	publicKey, err := x509.MarshalPKIXPublicKey(rsaKey.Public)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	confusedKey := Key{Algorithm: "HS256", Secret: pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey})}
+
+	unsigned, err := marshalAndEncode(Header{Algorythm: "none", Type: "JWT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	critical, err := marshalAndEncode(Header{Algorythm: "RS256", Type: "JWT", KeyId: rsaKey.Id, Critical: []string{"exp"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name       string
+		token      string
+		algorithms []string
+		want       error
+	}{
+		{"alg none", unsigned + "." + parts[1] + ".", algorithms, ErrMalformed},
+		{"alg none with signature", unsigned + "." + parts[1] + "." + parts[2], append(algorithms, "none"), ErrAlgMismatch},
+		{"HS256 with public key", signTestToken(t, confusedKey, Header{Algorythm: "HS256", Type: "JWT", KeyId: rsaKey.Id}), algorithms, ErrAlgMismatch},
+		{"HS256 allowed with RSA key", signTestToken(t, confusedKey, Header{Algorythm: "HS256", Type: "JWT", KeyId: rsaKey.Id}), append(algorithms, "HS256"), ErrAlgMismatch},
+		{"RS256 token with kid of ES256 key", signTestToken(t, rsaKey, Header{Algorythm: "RS256", Type: "JWT", KeyId: ecdsaKey.Id}), algorithms, ErrAlgMismatch},
+		{"algorithm not allowed", valid, []string{"ES256"}, ErrAlgMismatch},
+		{"unknown kid", signTestToken(t, otherKey, Header{Algorythm: "RS256", Type: "JWT", KeyId: otherKey.Id}), algorithms, ErrBadSignature},
+		{"foreign key with known kid", signTestToken(t, otherKey, Header{Algorythm: "RS256", Type: "JWT", KeyId: rsaKey.Id}), algorithms, ErrBadSignature},
+		{"tampered payload", parts[0] + "." + parts[1] + "e30." + parts[2], algorithms, ErrBadSignature},
+		{"crit header", critical + "." + parts[1] + "." + parts[2], algorithms, ErrMalformed},
+		{"unsupported typ", signTestToken(t, rsaKey, Header{Algorythm: "RS256", Type: "JWE", KeyId: rsaKey.Id}), algorithms, ErrMalformed},
+		{"two parts", parts[0] + "." + parts[1], algorithms, ErrMalformed},
+		{"four parts", valid + ".", algorithms, ErrMalformed},
+		{"empty signature", parts[0] + "." + parts[1] + ".", algorithms, ErrMalformed},
+		{"malformed header", "!." + parts[1] + "." + parts[2], algorithms, ErrMalformed},
+		{"malformed signature", parts[0] + "." + parts[1] + ".!", algorithms, ErrMalformed},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := Parse[testPayload](c.token, keyring, c.algorithms)
+			if !errors.Is(err, c.want) {
+				t.Fatalf("Parse() = %v, want %v", err, c.want)
+			}
+		})
+	}
+}
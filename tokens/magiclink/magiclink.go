@@ -0,0 +1,32 @@
+package magiclink
+
+import (
+	"goauth/tokens/jwt"
+)
+
+// Получатель токенов ссылок для входа. Отличает их от ACCESS токенов, подписанных тем же ключом.
+const AUDIENCE = "magic-link"
+
+// Полезная нагрузка JWT токена ссылки для входа, отправляемой по электронной почте.
+type MagicLinkTokenPayload struct {
+	// Идентификатор пользователя, которому отправлена ссылка.
+	Subject int32 `json:"sub"`
+
+	// Зарегистрированные утверждения токена.
+	jwt.RegisteredClaims
+
+	// Идентификатор записи в таблице MAGIC_LINKS, через которую обеспечивается однократное использование ссылки.
+	Id int32 `json:"jti"`
+}
+
+// Вспомогательное средство для издания JWT токенов ссылок для входа.
+type Issuer = jwt.Issuer[MagicLinkTokenPayload]
+
+// Выдать новый токен ссылки для входа указанного пользователя.
+func New(issuer Issuer, userId int32, magicLinkId int32) jwt.Jwt[MagicLinkTokenPayload] {
+	return issuer.New(MagicLinkTokenPayload{
+		RegisteredClaims: issuer.Registered(),
+		Subject:          userId,
+		Id:               magicLinkId,
+	})
+}
@@ -0,0 +1,99 @@
+package opaque
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Префикс дайджеста токена, по которому он отличается от хэшей других форматов.
+const DIGEST_PREFIX = "hmac-sha256$"
+
+// Размер случайного секрета непрозрачного токена в байтах.
+const SECRET_SIZE = 32
+
+// Выдать новый непрозрачный токен вида `<идентификатор>.<секрет>` для записи с указанным идентификатором.
+func New(id int32) (string, error) {
+	secret := make([]byte, SECRET_SIZE)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.Itoa(int(id)) + "." + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// Получить идентификатор записи из непрозрачного токена.
+func Id(token string) (int32, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("the opaque token is malformed")
+	}
+
+	secret, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || len(secret) != SECRET_SIZE {
+		return 0, fmt.Errorf("the opaque token is malformed")
+	}
+
+	id, err := strconv.ParseInt(parts[0], 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("the opaque token is malformed")
+	}
+
+	return int32(id), nil
+}
+
+// Проверить, является ли указанное значение непрозрачным токеном.
+func IsOpaque(token string) bool {
+	_, err := Id(token)
+
+	return err == nil
+}
+
+// Средство вычисления дайджестов токенов для хранения в БД.
+//
+// Дайджест вычисляется функцией HMAC-SHA256 с секретным ключом от всего токена, поэтому,
+// в отличие от bcrypt, привязан к каждому байту токена и вычисляется быстро.
+type Digester struct {
+	// Секретный ключ.
+	Key []byte
+}
+
+// Вычислить дайджест указанного токена.
+func (s Digester) Digest(token string) string {
+	return DIGEST_PREFIX + base64.RawURLEncoding.EncodeToString(s.mac(token))
+}
+
+// Проверить за постоянное время, что дайджест соответствует указанному токену.
+func (s Digester) Verify(token string, digest string) error {
+	if !IsDigest(digest) {
+		return fmt.Errorf("the value is not a token digest")
+	}
+
+	expected, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(digest, DIGEST_PREFIX))
+	if err != nil {
+		return err
+	}
+
+	if !hmac.Equal(expected, s.mac(token)) {
+		return fmt.Errorf("the token does not match the digest")
+	}
+
+	return nil
+}
+
+// Проверить, является ли указанное значение дайджестом токена.
+func IsDigest(value string) bool {
+	return strings.HasPrefix(value, DIGEST_PREFIX)
+}
+
+func (s Digester) mac(token string) []byte {
+	mac := hmac.New(sha256.New, s.Key)
+	mac.Write([]byte(token))
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,32 @@
+package verification
+
+import (
+	"goauth/tokens/jwt"
+)
+
+// Получатель токенов подтверждения адреса электронной почты. Отличает их от ACCESS токенов, подписанных тем же ключом.
+const AUDIENCE = "email-verification"
+
+// Полезная нагрузка JWT токена подтверждения адреса электронной почты.
+type EmailVerificationTokenPayload struct {
+	// Идентификатор пользователя, адрес которого подтверждается.
+	Subject int32 `json:"sub"`
+
+	// Зарегистрированные утверждения токена.
+	jwt.RegisteredClaims
+
+	// Подтверждаемый адрес электронной почты.
+	Email string `json:"email"`
+}
+
+// Вспомогательное средство для издания JWT токенов подтверждения адреса электронной почты.
+type Issuer = jwt.Issuer[EmailVerificationTokenPayload]
+
+// Выдать новый токен подтверждения адреса электронной почты указанного пользователя.
+func New(issuer Issuer, userId int32, email string) jwt.Jwt[EmailVerificationTokenPayload] {
+	return issuer.New(EmailVerificationTokenPayload{
+		RegisteredClaims: issuer.Registered(),
+		Subject:          userId,
+		Email:            email,
+	})
+}
@@ -0,0 +1,104 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Размер секрета в байтах (160 бит, как рекомендует RFC 4226).
+const SECRET_SIZE = 20
+
+// Количество цифр кода.
+const DIGITS = 6
+
+// Длительность шага времени.
+const PERIOD = 30 * time.Second
+
+// Количество соседних шагов, коды которых также принимаются, чтобы компенсировать расхождение часов.
+const SKEW_STEPS = 1
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Сгенерировать новый секрет в кодировке Base32 без выравнивания.
+func GenerateSecret() (string, error) {
+	secret := make([]byte, SECRET_SIZE)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(secret), nil
+}
+
+// Получить URI `otpauth://` для добавления секрета в приложение-аутентификатор.
+func Uri(issuer string, account string, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(DIGITS))
+	query.Set("period", fmt.Sprint(int(PERIOD.Seconds())))
+
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+
+	// Приложения-аутентификаторы не раскодируют `+` в пробел, поэтому пробелы кодируются как `%20`.
+	return "otpauth://totp/" + label + "?" + strings.ReplaceAll(query.Encode(), "+", "%20")
+}
+
+// Вычислить код для указанного шага времени (RFC 6238).
+func Code(secret string, step int64) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for range DIGITS {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", DIGITS, value%modulo), nil
+}
+
+// Получить шаг времени для указанного момента.
+func Step(moment time.Time) int64 {
+	return moment.Unix() / int64(PERIOD.Seconds())
+}
+
+// Проверить код для указанного момента времени с учетом соседних шагов.
+//
+// Возвращает шаг, которому соответствует код, чтобы вызывающая сторона могла запретить повторное использование кода.
+func Verify(secret string, code string, moment time.Time) (int64, bool, error) {
+	current := Step(moment)
+
+	for step := current - SKEW_STEPS; step <= current+SKEW_STEPS; step++ {
+		expected, err := Code(secret, step)
+		if err != nil {
+			return 0, false, err
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
@@ -0,0 +1,71 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// Секрет "12345678901234567890" из RFC 6238, приложение B, в кодировке Base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// Тестовые векторы RFC 6238, приложение B, для SHA-1. Коды из 8 цифр усечены до `DIGITS` младших цифр.
+var codeVectors = []struct {
+	time int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestCode(t *testing.T) {
+	for _, vector := range codeVectors {
+		code, err := Code(rfcSecret, Step(time.Unix(vector.time, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if code != vector.code {
+			t.Errorf("Code() at %d = %s, want %s", vector.time, code, vector.code)
+		}
+	}
+}
+
+func TestVerifyAcceptsAdjacentSteps(t *testing.T) {
+	moment := time.Unix(1111111111, 0)
+
+	for offset := -SKEW_STEPS; offset <= SKEW_STEPS; offset++ {
+		step := Step(moment) + int64(offset)
+
+		code, err := Code(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		verifiedStep, ok, err := Verify(rfcSecret, code, moment)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !ok || verifiedStep != step {
+			t.Errorf("Verify() for step offset %d = %d, %t", offset, verifiedStep, ok)
+		}
+	}
+
+	code, err := Code(rfcSecret, Step(moment)+SKEW_STEPS+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, ok, err := Verify(rfcSecret, code, moment)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok {
+		t.Error("Verify() accepted a code outside of the skew window")
+	}
+}
@@ -0,0 +1,156 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Максимальная глубина вложенности разбираемых значений CBOR.
+const MAX_CBOR_DEPTH = 16
+
+// Разобрать первое значение CBOR (RFC 8949) и вернуть его вместе с оставшимися байтами.
+//
+// Поддерживается подмножество, достаточное для WebAuthn: целые числа, байтовые и текстовые строки
+// определенной длины, массивы, словари и простые значения false, true и null. Целые числа
+// возвращаются как int64, байтовые строки как []byte, словари как map[any]any.
+func decodeCbor(data []byte) (any, []byte, error) {
+	return decodeCborValue(data, 0)
+}
+
+func decodeCborValue(data []byte, depth int) (any, []byte, error) {
+	if depth > MAX_CBOR_DEPTH {
+		return nil, nil, fmt.Errorf("%w: CBOR nesting is too deep", ErrMalformed)
+	}
+
+	if len(data) == 0 {
+		return nil, nil, fmt.Errorf("%w: unexpected end of CBOR data", ErrMalformed)
+	}
+
+	major := data[0] >> 5
+	argument, rest, err := decodeCborArgument(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case 0:
+		if argument > 1<<63-1 {
+			return nil, nil, fmt.Errorf("%w: CBOR integer is too large", ErrMalformed)
+		}
+
+		return int64(argument), rest, nil
+	case 1:
+		if argument > 1<<63-1 {
+			return nil, nil, fmt.Errorf("%w: CBOR integer is too large", ErrMalformed)
+		}
+
+		return -1 - int64(argument), rest, nil
+	case 2, 3:
+		if argument > uint64(len(rest)) {
+			return nil, nil, fmt.Errorf("%w: unexpected end of CBOR data", ErrMalformed)
+		}
+
+		value := rest[:argument]
+		if major == 3 {
+			return string(value), rest[argument:], nil
+		}
+
+		return append([]byte{}, value...), rest[argument:], nil
+	case 4:
+		if argument > uint64(len(rest)) {
+			return nil, nil, fmt.Errorf("%w: unexpected end of CBOR data", ErrMalformed)
+		}
+
+		result := make([]any, 0, argument)
+		for range argument {
+			var item any
+			item, rest, err = decodeCborValue(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			result = append(result, item)
+		}
+
+		return result, rest, nil
+	case 5:
+		if argument > uint64(len(rest)) {
+			return nil, nil, fmt.Errorf("%w: unexpected end of CBOR data", ErrMalformed)
+		}
+
+		result := make(map[any]any, argument)
+		for range argument {
+			var key, item any
+			key, rest, err = decodeCborValue(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, fmt.Errorf("%w: CBOR map key of type %T is not supported", ErrMalformed, key)
+			}
+
+			item, rest, err = decodeCborValue(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			result[key] = item
+		}
+
+		return result, rest, nil
+	case 7:
+		switch argument {
+		case 20:
+			return false, rest, nil
+		case 21:
+			return true, rest, nil
+		case 22:
+			return nil, rest, nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("%w: CBOR major type %d with argument %d is not supported", ErrMalformed, major, argument)
+}
+
+// Разобрать аргумент заголовка значения CBOR.
+func decodeCborArgument(data []byte) (uint64, []byte, error) {
+	additional := data[0] & 0x1f
+	rest := data[1:]
+
+	var size int
+	switch {
+	case additional < 24:
+		return uint64(additional), rest, nil
+	case additional == 24:
+		size = 1
+	case additional == 25:
+		size = 2
+	case additional == 26:
+		size = 4
+	case additional == 27:
+		size = 8
+	default:
+		return 0, nil, fmt.Errorf("%w: CBOR indefinite length is not supported", ErrMalformed)
+	}
+
+	if len(rest) < size {
+		return 0, nil, fmt.Errorf("%w: unexpected end of CBOR data", ErrMalformed)
+	}
+
+	var argument uint64
+	switch size {
+	case 1:
+		argument = uint64(rest[0])
+	case 2:
+		argument = uint64(binary.BigEndian.Uint16(rest))
+	case 4:
+		argument = uint64(binary.BigEndian.Uint32(rest))
+	case 8:
+		argument = binary.BigEndian.Uint64(rest)
+	}
+
+	return argument, rest[size:], nil
+}
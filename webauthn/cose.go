@@ -0,0 +1,137 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+)
+
+// Идентификаторы алгоритмов COSE (RFC 9053), поддерживаемые для ключей учетных данных.
+const (
+	ALGORITHM_ES256 int64 = -7
+	ALGORITHM_EDDSA int64 = -8
+	ALGORITHM_RS256 int64 = -257
+)
+
+// Поддерживаемые алгоритмы в порядке предпочтения.
+var Algorithms = []int64{ALGORITHM_ES256, ALGORITHM_EDDSA, ALGORITHM_RS256}
+
+// Открытый ключ учетных данных в формате COSE (RFC 9052).
+type PublicKey struct {
+	// Алгоритм COSE, для которого предназначен ключ.
+	Algorithm int64
+
+	// Открытый ключ.
+	Key crypto.PublicKey
+}
+
+// Разобрать открытый ключ в формате COSE.
+func ParsePublicKey(value []byte) (*PublicKey, error) {
+	decoded, rest, err := decodeCbor(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: trailing bytes after COSE key", ErrMalformed)
+	}
+
+	parameters, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: COSE key is not a map", ErrMalformed)
+	}
+
+	keyType, _ := parameters[int64(1)].(int64)
+	algorithm, _ := parameters[int64(3)].(int64)
+
+	switch {
+	case keyType == 2 && algorithm == ALGORITHM_ES256:
+		return parseEcdsaKey(parameters, algorithm)
+	case keyType == 1 && algorithm == ALGORITHM_EDDSA:
+		return parseEd25519Key(parameters, algorithm)
+	case keyType == 3 && algorithm == ALGORITHM_RS256:
+		return parseRsaKey(parameters, algorithm)
+	}
+
+	return nil, fmt.Errorf("%w: COSE key type %d with algorithm %d is not supported", ErrUnsupported, keyType, algorithm)
+}
+
+// Проверить подпись указанных данных. Подпись ECDSA ожидается в формате ASN.1 DER, как того требует WebAuthn.
+func (s PublicKey) Verify(data []byte, signature []byte) error {
+	return verifySignature(s.Algorithm, s.Key, data, signature)
+}
+
+func verifySignature(algorithm int64, key crypto.PublicKey, data []byte, signature []byte) error {
+	valid := false
+	hash := sha256.Sum256(data)
+
+	switch public := key.(type) {
+	case *ecdsa.PublicKey:
+		valid = algorithm == ALGORITHM_ES256 && ecdsa.VerifyASN1(public, hash[:], signature)
+	case ed25519.PublicKey:
+		valid = algorithm == ALGORITHM_EDDSA && ed25519.Verify(public, data, signature)
+	case *rsa.PublicKey:
+		valid = algorithm == ALGORITHM_RS256 && rsa.VerifyPKCS1v15(public, crypto.SHA256, hash[:], signature) == nil
+	}
+
+	if !valid {
+		return fmt.Errorf("%w: the signature is not valid", ErrVerification)
+	}
+
+	return nil
+}
+
+func parseEcdsaKey(parameters map[any]any, algorithm int64) (*PublicKey, error) {
+	curve, _ := parameters[int64(-1)].(int64)
+	x, okX := parameters[int64(-2)].([]byte)
+	y, okY := parameters[int64(-3)].([]byte)
+
+	if curve != 1 || !okX || !okY || len(x) != 32 || len(y) != 32 {
+		return nil, fmt.Errorf("%w: COSE EC2 key is not a P-256 key", ErrUnsupported)
+	}
+
+	key := &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}
+
+	if !key.Curve.IsOnCurve(key.X, key.Y) {
+		return nil, fmt.Errorf("%w: COSE EC2 point is not on the curve", ErrMalformed)
+	}
+
+	return &PublicKey{Algorithm: algorithm, Key: key}, nil
+}
+
+func parseEd25519Key(parameters map[any]any, algorithm int64) (*PublicKey, error) {
+	curve, _ := parameters[int64(-1)].(int64)
+	x, ok := parameters[int64(-2)].([]byte)
+
+	if curve != 6 || !ok || len(x) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: COSE OKP key is not an Ed25519 key", ErrUnsupported)
+	}
+
+	return &PublicKey{Algorithm: algorithm, Key: ed25519.PublicKey(x)}, nil
+}
+
+func parseRsaKey(parameters map[any]any, algorithm int64) (*PublicKey, error) {
+	modulus, okN := parameters[int64(-1)].([]byte)
+	exponent, okE := parameters[int64(-2)].([]byte)
+
+	if !okN || !okE || len(modulus) < 256 || len(exponent) == 0 || len(exponent) > 4 {
+		return nil, fmt.Errorf("%w: COSE RSA key is malformed", ErrMalformed)
+	}
+
+	return &PublicKey{
+		Algorithm: algorithm,
+		Key: &rsa.PublicKey{
+			N: new(big.Int).SetBytes(modulus),
+			E: int(new(big.Int).SetBytes(exponent).Int64()),
+		},
+	}, nil
+}
@@ -0,0 +1,105 @@
+package webauthn
+
+// Время, отводимое пользователю на церемонию, в миллисекундах.
+const TIMEOUT_IN_MILLISECONDS = 5 * 60 * 1000
+
+// Описание учетных данных в параметрах церемонии.
+type CredentialDescriptor struct {
+	// Тип учетных данных, всегда "public-key".
+	Type string `json:"type"`
+
+	// Идентификатор учетных данных в Base64URL.
+	Id string `json:"id"`
+}
+
+// Параметры для `navigator.credentials.create()`. Двоичные поля кодируются в Base64URL.
+type CreationOptions struct {
+	Challenge string `json:"challenge"`
+
+	RelyingParty struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"rp"`
+
+	User struct {
+		Id          string `json:"id"`
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+	} `json:"user"`
+
+	PublicKeyCredentialParameters []struct {
+		Type      string `json:"type"`
+		Algorithm int64  `json:"alg"`
+	} `json:"pubKeyCredParams"`
+
+	Timeout int `json:"timeout"`
+
+	Attestation string `json:"attestation"`
+
+	AuthenticatorSelection struct {
+		ResidentKey      string `json:"residentKey"`
+		UserVerification string `json:"userVerification"`
+	} `json:"authenticatorSelection"`
+
+	ExcludeCredentials []CredentialDescriptor `json:"excludeCredentials"`
+}
+
+// Параметры для `navigator.credentials.get()`. Двоичные поля кодируются в Base64URL.
+type RequestOptions struct {
+	Challenge string `json:"challenge"`
+
+	RelyingPartyId string `json:"rpId"`
+
+	Timeout int `json:"timeout"`
+
+	UserVerification string `json:"userVerification"`
+
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+}
+
+// Получить параметры церемонии регистрации обнаруживаемых учетных данных (passkey) для указанного пользователя.
+func (s RelyingParty) CreationOptions(challenge []byte, userHandle []byte, userName string, excluded [][]byte) CreationOptions {
+	result := CreationOptions{
+		Challenge:          Encode(challenge),
+		Timeout:            TIMEOUT_IN_MILLISECONDS,
+		Attestation:        "none",
+		ExcludeCredentials: descriptors(excluded),
+	}
+
+	result.RelyingParty.Id = s.Id
+	result.RelyingParty.Name = s.Name
+	result.User.Id = Encode(userHandle)
+	result.User.Name = userName
+	result.User.DisplayName = userName
+	result.AuthenticatorSelection.ResidentKey = "required"
+	result.AuthenticatorSelection.UserVerification = "preferred"
+
+	for _, algorithm := range Algorithms {
+		result.PublicKeyCredentialParameters = append(result.PublicKeyCredentialParameters, struct {
+			Type      string `json:"type"`
+			Algorithm int64  `json:"alg"`
+		}{"public-key", algorithm})
+	}
+
+	return result
+}
+
+// Получить параметры церемонии аутентификации обнаруживаемыми учетными данными.
+func (s RelyingParty) RequestOptions(challenge []byte) RequestOptions {
+	return RequestOptions{
+		Challenge:        Encode(challenge),
+		RelyingPartyId:   s.Id,
+		Timeout:          TIMEOUT_IN_MILLISECONDS,
+		UserVerification: "preferred",
+		AllowCredentials: []CredentialDescriptor{},
+	}
+}
+
+func descriptors(ids [][]byte) []CredentialDescriptor {
+	result := []CredentialDescriptor{}
+	for _, id := range ids {
+		result = append(result, CredentialDescriptor{Type: "public-key", Id: Encode(id)})
+	}
+
+	return result
+}
@@ -0,0 +1,391 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Данные церемонии имеют недопустимый формат.
+var ErrMalformed = errors.New("the WebAuthn data is malformed")
+
+// Формат, алгоритм или тип ключа не поддерживается.
+var ErrUnsupported = errors.New("the WebAuthn data is not supported")
+
+// Данные церемонии не прошли проверку.
+var ErrVerification = errors.New("the WebAuthn ceremony verification failed")
+
+// Счетчик подписей аутентификатора не увеличился: аутентификатор мог быть скопирован.
+var ErrSignCount = fmt.Errorf("%w: the sign count has not increased, the authenticator may be cloned", ErrVerification)
+
+// Флаги данных аутентификатора.
+const (
+	FLAG_USER_PRESENT         byte = 0x01
+	FLAG_USER_VERIFIED        byte = 0x04
+	FLAG_ATTESTED_CREDENTIALS byte = 0x40
+	FLAG_EXTENSIONS           byte = 0x80
+)
+
+// Проверяющая сторона (Relying Party).
+type RelyingParty struct {
+	// Идентификатор проверяющей стороны: доменное имя, для которого создаются учетные данные.
+	Id string
+
+	// Отображаемое название проверяющей стороны.
+	Name string
+
+	// Допустимый источник (origin) страниц, выполняющих церемонии.
+	Origin string
+}
+
+// Учетные данные, возвращаемые браузером из `navigator.credentials.create()` или `navigator.credentials.get()`.
+//
+// Двоичные поля кодируются в Base64URL.
+type Credential struct {
+	// Идентификатор учетных данных.
+	Id string `json:"id"`
+
+	// Тип учетных данных, всегда "public-key".
+	Type string `json:"type"`
+
+	// Ответ аутентификатора.
+	Response struct {
+		// Данные клиента в формате JSON.
+		ClientDataJson string `json:"clientDataJSON"`
+
+		// Объект аттестации. Только для регистрации.
+		AttestationObject string `json:"attestationObject,omitempty"`
+
+		// Данные аутентификатора. Только для аутентификации.
+		AuthenticatorData string `json:"authenticatorData,omitempty"`
+
+		// Подпись. Только для аутентификации.
+		Signature string `json:"signature,omitempty"`
+
+		// Идентификатор пользователя, сохраненный в учетных данных. Только для аутентификации.
+		UserHandle string `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+// Данные аутентификатора (раздел 6.1 спецификации WebAuthn).
+type AuthenticatorData struct {
+	// SHA-256 от идентификатора проверяющей стороны.
+	RpIdHash []byte
+
+	// Флаги.
+	Flags byte
+
+	// Счетчик подписей.
+	SignCount uint32
+
+	// Данные созданных учетных данных. Только для регистрации.
+	AttestedCredential *AttestedCredential
+}
+
+// Данные созданных учетных данных.
+type AttestedCredential struct {
+	// Идентификатор модели аутентификатора.
+	Aaguid []byte
+
+	// Идентификатор учетных данных.
+	Id []byte
+
+	// Открытый ключ в формате COSE.
+	PublicKey []byte
+}
+
+// Проверить ответ на церемонию регистрации и получить данные созданных учетных данных.
+//
+// Поддерживаются форматы аттестации "none" и "packed". Цепочка сертификатов аттестации не проверяется.
+func (s RelyingParty) VerifyRegistration(challenge []byte, credential Credential) (*AuthenticatorData, error) {
+	clientDataJson, err := Decode(credential.Response.ClientDataJson)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.verifyClientData(clientDataJson, "webauthn.create", challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	attestationObject, err := Decode(credential.Response.AttestationObject)
+	if err != nil {
+		return nil, err
+	}
+
+	format, statement, rawAuthenticatorData, err := parseAttestationObject(attestationObject)
+	if err != nil {
+		return nil, err
+	}
+
+	authenticatorData, err := s.verifyAuthenticatorData(rawAuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+
+	if authenticatorData.AttestedCredential == nil {
+		return nil, fmt.Errorf("%w: authenticator data has no attested credential", ErrVerification)
+	}
+
+	publicKey, err := ParsePublicKey(authenticatorData.AttestedCredential.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJson)
+	signed := append(append([]byte{}, rawAuthenticatorData...), clientDataHash[:]...)
+
+	switch format {
+	case "none":
+	case "packed":
+		err = verifyPackedStatement(statement, signed, publicKey)
+	default:
+		err = fmt.Errorf("%w: attestation format %s", ErrUnsupported, format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return authenticatorData, nil
+}
+
+// Проверить ответ на церемонию аутентификации с помощью сохраненного открытого ключа и счетчика подписей.
+func (s RelyingParty) VerifyAssertion(challenge []byte, credential Credential, publicKey []byte, storedSignCount uint32) (*AuthenticatorData, error) {
+	clientDataJson, err := Decode(credential.Response.ClientDataJson)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.verifyClientData(clientDataJson, "webauthn.get", challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	rawAuthenticatorData, err := Decode(credential.Response.AuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+
+	authenticatorData, err := s.verifyAuthenticatorData(rawAuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := Decode(credential.Response.Signature)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJson)
+	err = key.Verify(append(append([]byte{}, rawAuthenticatorData...), clientDataHash[:]...), signature)
+	if err != nil {
+		return nil, err
+	}
+
+	if (authenticatorData.SignCount != 0 || storedSignCount != 0) && authenticatorData.SignCount <= storedSignCount {
+		return nil, ErrSignCount
+	}
+
+	return authenticatorData, nil
+}
+
+// Получить идентификатор учетных данных.
+func (s Credential) RawId() ([]byte, error) {
+	return Decode(s.Id)
+}
+
+// Получить идентификатор пользователя, сохраненный в учетных данных.
+func (s Credential) UserHandle() ([]byte, error) {
+	return Decode(s.Response.UserHandle)
+}
+
+// Проверить данные клиента: тип церемонии, вызов и источник.
+func (s RelyingParty) verifyClientData(clientDataJson []byte, ceremony string, challenge []byte) error {
+	var clientData struct {
+		Type        string `json:"type"`
+		Challenge   string `json:"challenge"`
+		Origin      string `json:"origin"`
+		CrossOrigin bool   `json:"crossOrigin"`
+	}
+
+	err := json.Unmarshal(clientDataJson, &clientData)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	if clientData.Type != ceremony {
+		return fmt.Errorf("%w: unexpected client data type %s", ErrVerification, clientData.Type)
+	}
+
+	actualChallenge, err := Decode(clientData.Challenge)
+	if err != nil || subtle.ConstantTimeCompare(actualChallenge, challenge) != 1 {
+		return fmt.Errorf("%w: the challenge does not match", ErrVerification)
+	}
+
+	if clientData.Origin != s.Origin || clientData.CrossOrigin {
+		return fmt.Errorf("%w: unexpected origin %s", ErrVerification, clientData.Origin)
+	}
+
+	return nil
+}
+
+// Разобрать данные аутентификатора и проверить идентификатор проверяющей стороны и присутствие пользователя.
+func (s RelyingParty) verifyAuthenticatorData(value []byte) (*AuthenticatorData, error) {
+	result, err := ParseAuthenticatorData(value)
+	if err != nil {
+		return nil, err
+	}
+
+	rpIdHash := sha256.Sum256([]byte(s.Id))
+	if !bytes.Equal(result.RpIdHash, rpIdHash[:]) {
+		return nil, fmt.Errorf("%w: the relying party id does not match", ErrVerification)
+	}
+
+	if result.Flags&FLAG_USER_PRESENT == 0 {
+		return nil, fmt.Errorf("%w: the user is not present", ErrVerification)
+	}
+
+	return result, nil
+}
+
+// Разобрать данные аутентификатора.
+func ParseAuthenticatorData(value []byte) (*AuthenticatorData, error) {
+	if len(value) < 37 {
+		return nil, fmt.Errorf("%w: authenticator data is too short", ErrMalformed)
+	}
+
+	result := &AuthenticatorData{
+		RpIdHash:  value[:32],
+		Flags:     value[32],
+		SignCount: binary.BigEndian.Uint32(value[33:37]),
+	}
+
+	rest := value[37:]
+
+	if result.Flags&FLAG_ATTESTED_CREDENTIALS != 0 {
+		if len(rest) < 18 {
+			return nil, fmt.Errorf("%w: attested credential data is too short", ErrMalformed)
+		}
+
+		idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		if len(rest) < 18+idLength {
+			return nil, fmt.Errorf("%w: attested credential data is too short", ErrMalformed)
+		}
+
+		credential := &AttestedCredential{
+			Aaguid: rest[:16],
+			Id:     rest[18 : 18+idLength],
+		}
+
+		keyAndExtensions := rest[18+idLength:]
+		_, afterKey, err := decodeCbor(keyAndExtensions)
+		if err != nil {
+			return nil, err
+		}
+
+		credential.PublicKey = keyAndExtensions[:len(keyAndExtensions)-len(afterKey)]
+		result.AttestedCredential = credential
+		rest = afterKey
+	}
+
+	if result.Flags&FLAG_EXTENSIONS != 0 {
+		_, afterExtensions, err := decodeCbor(rest)
+		if err != nil {
+			return nil, err
+		}
+
+		rest = afterExtensions
+	}
+
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: trailing bytes after authenticator data", ErrMalformed)
+	}
+
+	return result, nil
+}
+
+// Разобрать объект аттестации на формат, заявление аттестации и данные аутентификатора.
+func parseAttestationObject(value []byte) (string, map[any]any, []byte, error) {
+	decoded, _, err := decodeCbor(value)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	object, ok := decoded.(map[any]any)
+	if !ok {
+		return "", nil, nil, fmt.Errorf("%w: attestation object is not a map", ErrMalformed)
+	}
+
+	format, okFormat := object["fmt"].(string)
+	statement, okStatement := object["attStmt"].(map[any]any)
+	authenticatorData, okData := object["authData"].([]byte)
+
+	if !okFormat || !okStatement || !okData {
+		return "", nil, nil, fmt.Errorf("%w: attestation object is incomplete", ErrMalformed)
+	}
+
+	return format, statement, authenticatorData, nil
+}
+
+// Проверить заявление аттестации формата "packed": самоаттестацию ключом учетных данных или подпись сертификатом.
+func verifyPackedStatement(statement map[any]any, signed []byte, publicKey *PublicKey) error {
+	algorithm, okAlgorithm := statement["alg"].(int64)
+	signature, okSignature := statement["sig"].([]byte)
+
+	if !okAlgorithm || !okSignature {
+		return fmt.Errorf("%w: packed attestation statement is incomplete", ErrMalformed)
+	}
+
+	certificates, hasCertificates := statement["x5c"].([]any)
+	if !hasCertificates {
+		if algorithm != publicKey.Algorithm {
+			return fmt.Errorf("%w: self attestation algorithm does not match the credential key", ErrVerification)
+		}
+
+		return publicKey.Verify(signed, signature)
+	}
+
+	if len(certificates) == 0 {
+		return fmt.Errorf("%w: packed attestation has an empty certificate chain", ErrMalformed)
+	}
+
+	der, ok := certificates[0].([]byte)
+	if !ok {
+		return fmt.Errorf("%w: attestation certificate is not a byte string", ErrMalformed)
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	return verifySignature(algorithm, certificate.PublicKey, signed, signature)
+}
+
+// Раскодировать значение Base64URL с выравниванием или без него.
+func Decode(value string) ([]byte, error) {
+	result, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	return result, nil
+}
+
+// Закодировать значение в Base64URL без выравнивания.
+func Encode(value []byte) string {
+	return base64.RawURLEncoding.EncodeToString(value)
+}
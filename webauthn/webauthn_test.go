@@ -0,0 +1,270 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+var testRelyingParty = RelyingParty{
+	Id:     "example.com",
+	Name:   "Example",
+	Origin: "https://example.com",
+}
+
+// Программный аутентификатор с ключом ES256 для проверки церемоний без браузера.
+type softwareAuthenticator struct {
+	credentialId []byte
+	key          *ecdsa.PrivateKey
+	signCount    uint32
+}
+
+// Параметры ответа аутентификатора, которые тесты могут подменять.
+type ceremonyParameters struct {
+	origin    string
+	rpId      string
+	challenge []byte
+	signCount uint32
+}
+
+func newSoftwareAuthenticator(t *testing.T) *softwareAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credentialId := make([]byte, 16)
+	_, err = rand.Read(credentialId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &softwareAuthenticator{credentialId: credentialId, key: key}
+}
+
+func validParameters(challenge []byte, signCount uint32) ceremonyParameters {
+	return ceremonyParameters{
+		origin:    testRelyingParty.Origin,
+		rpId:      testRelyingParty.Id,
+		challenge: challenge,
+		signCount: signCount,
+	}
+}
+
+// Получить открытый ключ в формате COSE.
+func (s *softwareAuthenticator) publicKey() []byte {
+	return encodeCbor(cborMap{
+		{int64(1), int64(2)},
+		{int64(3), ALGORITHM_ES256},
+		{int64(-1), int64(1)},
+		{int64(-2), s.key.X.FillBytes(make([]byte, 32))},
+		{int64(-3), s.key.Y.FillBytes(make([]byte, 32))},
+	})
+}
+
+// Создать учетные данные с самоаттестацией формата "packed", как `navigator.credentials.create()`.
+func (s *softwareAuthenticator) create(t *testing.T, parameters ceremonyParameters) Credential {
+	clientDataJson := clientData(t, "webauthn.create", parameters)
+
+	attestedCredential := append(make([]byte, 16), 0, byte(len(s.credentialId)))
+	attestedCredential = append(append(attestedCredential, s.credentialId...), s.publicKey()...)
+
+	authenticatorData := authenticatorData(parameters, FLAG_USER_PRESENT|FLAG_USER_VERIFIED|FLAG_ATTESTED_CREDENTIALS, attestedCredential)
+
+	attestationObject := encodeCbor(cborMap{
+		{"fmt", "packed"},
+		{"attStmt", cborMap{
+			{"alg", ALGORITHM_ES256},
+			{"sig", s.sign(t, authenticatorData, clientDataJson)},
+		}},
+		{"authData", authenticatorData},
+	})
+
+	var credential Credential
+	credential.Id = Encode(s.credentialId)
+	credential.Type = "public-key"
+	credential.Response.ClientDataJson = Encode(clientDataJson)
+	credential.Response.AttestationObject = Encode(attestationObject)
+
+	return credential
+}
+
+// Подписать вызов, как `navigator.credentials.get()`.
+func (s *softwareAuthenticator) get(t *testing.T, parameters ceremonyParameters) Credential {
+	clientDataJson := clientData(t, "webauthn.get", parameters)
+	authenticatorData := authenticatorData(parameters, FLAG_USER_PRESENT, nil)
+
+	var credential Credential
+	credential.Id = Encode(s.credentialId)
+	credential.Type = "public-key"
+	credential.Response.ClientDataJson = Encode(clientDataJson)
+	credential.Response.AuthenticatorData = Encode(authenticatorData)
+	credential.Response.Signature = Encode(s.sign(t, authenticatorData, clientDataJson))
+
+	return credential
+}
+
+func (s *softwareAuthenticator) sign(t *testing.T, authenticatorData []byte, clientDataJson []byte) []byte {
+	clientDataHash := sha256.Sum256(clientDataJson)
+	hash := sha256.Sum256(append(append([]byte{}, authenticatorData...), clientDataHash[:]...))
+
+	signature, err := ecdsa.SignASN1(rand.Reader, s.key, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signature
+}
+
+func clientData(t *testing.T, ceremony string, parameters ceremonyParameters) []byte {
+	result, err := json.Marshal(map[string]any{
+		"type":      ceremony,
+		"challenge": Encode(parameters.challenge),
+		"origin":    parameters.origin,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
+
+func authenticatorData(parameters ceremonyParameters, flags byte, attestedCredential []byte) []byte {
+	rpIdHash := sha256.Sum256([]byte(parameters.rpId))
+
+	result := append(rpIdHash[:], flags)
+	result = binary.BigEndian.AppendUint32(result, parameters.signCount)
+
+	return append(result, attestedCredential...)
+}
+
+func TestRegistrationAndAssertionRoundTrip(t *testing.T) {
+	authenticator := newSoftwareAuthenticator(t)
+	registrationChallenge := []byte("registration challenge")
+
+	registered, err := testRelyingParty.VerifyRegistration(registrationChallenge, authenticator.create(t, validParameters(registrationChallenge, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(registered.AttestedCredential.Id) != string(authenticator.credentialId) {
+		t.Fatal("VerifyRegistration() returned another credential id")
+	}
+
+	if registered.Flags&FLAG_USER_VERIFIED == 0 {
+		t.Fatal("VerifyRegistration() lost the user verification flag")
+	}
+
+	assertionChallenge := []byte("assertion challenge")
+
+	asserted, err := testRelyingParty.VerifyAssertion(assertionChallenge, authenticator.get(t, validParameters(assertionChallenge, 1)),
+		registered.AttestedCredential.PublicKey, registered.SignCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if asserted.SignCount != 1 {
+		t.Fatalf("VerifyAssertion().SignCount = %d, want 1", asserted.SignCount)
+	}
+}
+
+func TestCeremoniesRejectMismatchedClientAndAuthenticatorData(t *testing.T) {
+	authenticator := newSoftwareAuthenticator(t)
+	challenge := []byte("challenge")
+
+	cases := map[string]func(*ceremonyParameters){
+		"wrong origin":    func(p *ceremonyParameters) { p.origin = "https://evil.example" },
+		"wrong rpIdHash":  func(p *ceremonyParameters) { p.rpId = "evil.example" },
+		"wrong challenge": func(p *ceremonyParameters) { p.challenge = []byte("another challenge") },
+	}
+
+	for name, modify := range cases {
+		t.Run(name, func(t *testing.T) {
+			parameters := validParameters(challenge, 1)
+			modify(&parameters)
+
+			_, err := testRelyingParty.VerifyRegistration(challenge, authenticator.create(t, parameters))
+			if !errors.Is(err, ErrVerification) {
+				t.Errorf("VerifyRegistration() = %v, want ErrVerification", err)
+			}
+
+			_, err = testRelyingParty.VerifyAssertion(challenge, authenticator.get(t, parameters), authenticator.publicKey(), 0)
+			if !errors.Is(err, ErrVerification) {
+				t.Errorf("VerifyAssertion() = %v, want ErrVerification", err)
+			}
+		})
+	}
+}
+
+func TestAssertionRejectsForeignSignature(t *testing.T) {
+	authenticator := newSoftwareAuthenticator(t)
+	another := newSoftwareAuthenticator(t)
+	challenge := []byte("challenge")
+
+	_, err := testRelyingParty.VerifyAssertion(challenge, another.get(t, validParameters(challenge, 1)), authenticator.publicKey(), 0)
+	if !errors.Is(err, ErrVerification) {
+		t.Fatalf("VerifyAssertion() = %v, want ErrVerification", err)
+	}
+}
+
+func TestAssertionRejectsSignCountRegression(t *testing.T) {
+	authenticator := newSoftwareAuthenticator(t)
+	challenge := []byte("challenge")
+
+	for _, signCount := range []uint32{4, 5} {
+		_, err := testRelyingParty.VerifyAssertion(challenge, authenticator.get(t, validParameters(challenge, signCount)), authenticator.publicKey(), 5)
+		if !errors.Is(err, ErrSignCount) {
+			t.Errorf("VerifyAssertion() with sign count %d after 5 = %v, want ErrSignCount", signCount, err)
+		}
+	}
+
+	_, err := testRelyingParty.VerifyAssertion(challenge, authenticator.get(t, validParameters(challenge, 0)), authenticator.publicKey(), 0)
+	if err != nil {
+		t.Errorf("VerifyAssertion() of an authenticator without a sign count = %v", err)
+	}
+}
+
+// Словарь CBOR с заданным порядком ключей.
+type cborMap [][2]any
+
+// Закодировать значение в CBOR. Поддерживаются только типы, нужные для данных WebAuthn.
+func encodeCbor(value any) []byte {
+	switch typed := value.(type) {
+	case int64:
+		if typed < 0 {
+			return cborHeader(1, uint64(-1-typed))
+		}
+
+		return cborHeader(0, uint64(typed))
+	case []byte:
+		return append(cborHeader(2, uint64(len(typed))), typed...)
+	case string:
+		return append(cborHeader(3, uint64(len(typed))), typed...)
+	case cborMap:
+		result := cborHeader(5, uint64(len(typed)))
+		for _, pair := range typed {
+			result = append(result, encodeCbor(pair[0])...)
+			result = append(result, encodeCbor(pair[1])...)
+		}
+
+		return result
+	}
+
+	panic("unsupported CBOR value")
+}
+
+func cborHeader(major byte, argument uint64) []byte {
+	switch {
+	case argument < 24:
+		return []byte{major<<5 | byte(argument)}
+	case argument <= 0xff:
+		return []byte{major<<5 | 24, byte(argument)}
+	default:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(argument))
+	}
+}